@@ -0,0 +1,349 @@
+package agreement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"loan-service/entity"
+	"loan-service/utils/money"
+	"strconv"
+	"text/template"
+	"time"
+
+	"codeberg.org/go-pdf/fpdf"
+	"github.com/shopspring/decimal"
+)
+
+// Template lays out an agreement, every text is a text/template executed against Data. A template is never changed
+// once released, a new version is added instead so the agreements already issued can be rendered again as they were.
+type Template struct {
+	Version    string
+	Title      string
+	Preamble   string
+	Terms      []Term
+	Schedule   bool
+	Clauses    []Clause
+	Signatures []Signature
+}
+
+// Term is a labelled value in the terms table
+type Term struct {
+	Label string
+	Value string
+}
+
+type Clause struct {
+	Heading string
+	Body    string
+}
+
+// Signature is a signature block, Party names the role of the signer and Name who signs
+type Signature struct {
+	Party string
+	Name  string
+}
+
+// Party is the borrower or investor an agreement is made with
+type Party struct {
+	ID    uint
+	Name  string
+	Email string
+}
+
+// LoanTerms are the loan terms as printed on an agreement
+type LoanTerms struct {
+	ID        uint
+	Principal string
+	Currency  string
+	Rate      string
+	ROI       string
+	Tenor     int
+	Frequency string
+}
+
+// InvestmentTerms are the terms of an investment as printed on the agreement of the investor, Share is the percentage of
+// the loan principal held and ExpectedReturn the return earned when the loan is repaid in full
+type InvestmentTerms struct {
+	ID             uint
+	Amount         string
+	Share          string
+	ExpectedReturn string
+}
+
+// ScheduleRow is an installment as printed in the repayment schedule table
+type ScheduleRow struct {
+	Sequence    int
+	DueDate     string
+	Principal   string
+	Interest    string
+	Amount      string
+	Outstanding string
+}
+
+// Data fills the placeholders of a template. Issued is both the date printed on the agreement and the date stored in
+// the PDF metadata, so the same data always renders the same document.
+type Data struct {
+	Issued     time.Time
+	Borrower   Party
+	Investor   Party
+	Loan       LoanTerms
+	Investment InvestmentTerms
+	Schedule   []ScheduleRow
+}
+
+// Date is the issue date as printed on the agreement
+func (d Data) Date() string {
+	return d.Issued.Format("2 January 2006")
+}
+
+// NewLoanData prepares the data of a loan agreement, the schedule is the repayment schedule offered to the borrower
+func NewLoanData(loan entity.Loan, borrower entity.User, schedule []entity.Installment) Data {
+	data := Data{
+		Issued:   loan.CreatedAt,
+		Borrower: newParty(borrower),
+		Loan:     newLoanTerms(loan),
+		Schedule: make([]ScheduleRow, 0, len(schedule)),
+	}
+	for _, installment := range schedule {
+		data.Schedule = append(data.Schedule, ScheduleRow{
+			Sequence:    installment.Sequence,
+			DueDate:     installment.DueDate.Format("2006-01-02"),
+			Principal:   money.Format(installment.Principal, loan.Currency),
+			Interest:    money.Format(installment.Interest, loan.Currency),
+			Amount:      money.Format(installment.Amount, loan.Currency),
+			Outstanding: money.Format(installment.OutstandingBalance, loan.Currency),
+		})
+	}
+	return data
+}
+
+// NewInvestmentData prepares the data of the agreement of an investor for one investment in the loan
+func NewInvestmentData(loan entity.Loan, investment entity.Investment, investor entity.User) Data {
+	hundred := decimal.NewFromInt(100)
+	share := decimal.Zero
+	if loan.Principal.IsPositive() {
+		share = investment.Amount.Mul(hundred).Div(loan.Principal)
+	}
+	return Data{
+		Issued:   investment.CreatedAt,
+		Investor: newParty(investor),
+		Loan:     newLoanTerms(loan),
+		Investment: InvestmentTerms{
+			ID:             investment.ID,
+			Amount:         money.Format(investment.Amount, loan.Currency),
+			Share:          share.StringFixed(2),
+			ExpectedReturn: money.Format(investment.Amount.Mul(loan.ROI).Div(hundred), loan.Currency),
+		},
+	}
+}
+
+func newParty(user entity.User) Party {
+	return Party{
+		ID:    user.ID,
+		Name:  user.Username,
+		Email: user.Email,
+	}
+}
+
+func newLoanTerms(loan entity.Loan) LoanTerms {
+	return LoanTerms{
+		ID:        loan.ID,
+		Principal: money.Format(loan.Principal, loan.Currency),
+		Currency:  string(loan.Currency),
+		Rate:      loan.Rate.StringFixed(2),
+		ROI:       loan.ROI.StringFixed(2),
+		Tenor:     loan.Tenor,
+		Frequency: string(loan.Frequency),
+	}
+}
+
+const (
+	lineHeight = 6.0
+	rowHeight  = 7.0
+)
+
+var scheduleColumns = []struct {
+	header string
+	width  float64
+}{
+	{"No.", 12},
+	{"Due date", 30},
+	{"Principal", 32},
+	{"Interest", 32},
+	{"Amount", 32},
+	{"Outstanding", 32},
+}
+
+// RenderLoan writes the loan agreement of the given template version as a PDF
+func RenderLoan(w io.Writer, version string, data Data) error {
+	return render(w, loanTemplates, version, data)
+}
+
+// RenderInvestment writes the investment agreement of the given template version as a PDF
+func RenderInvestment(w io.Writer, version string, data Data) error {
+	return render(w, investmentTemplates, version, data)
+}
+
+func render(w io.Writer, templates map[string]Template, version string, data Data) error {
+	tpl, ok := templates[version]
+	if !ok {
+		return fmt.Errorf("agreement: unknown template version %q", version)
+	}
+	r := renderer{data: data, pdf: fpdf.New("P", "mm", "A4", "")}
+	return r.render(w, tpl)
+}
+
+type renderer struct {
+	data Data
+	pdf  *fpdf.Fpdf
+	tr   func(string) string
+	err  error
+}
+
+// text executes a placeholder text of the template, the first failure is kept and fails the rendering
+func (r *renderer) text(name, text string) string {
+	if r.err != nil {
+		return ""
+	}
+	tpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		r.err = fmt.Errorf("agreement: %s: %w", name, err)
+		return ""
+	}
+	var out bytes.Buffer
+	if err := tpl.Execute(&out, r.data); err != nil {
+		r.err = fmt.Errorf("agreement: %s: %w", name, err)
+		return ""
+	}
+	return r.tr(out.String())
+}
+
+func (r *renderer) render(w io.Writer, tpl Template) error {
+	pdf := r.pdf
+	r.tr = pdf.UnicodeTranslatorFromDescriptor("")
+	title := r.text("title", tpl.Title)
+
+	pdf.SetCatalogSort(true)
+	pdf.SetCreationDate(r.data.Issued)
+	pdf.SetModificationDate(r.data.Issued)
+	pdf.SetTitle(title, false)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Agreement template %s - page %d of {nb}", tpl.Version, pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(0, 10, title, "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(0, lineHeight, r.text("date", "Date: {{.Date}}"), "", 1, "R", false, 0, "")
+	pdf.Ln(4)
+
+	if tpl.Preamble != "" {
+		pdf.MultiCell(0, lineHeight, r.text("preamble", tpl.Preamble), "", "J", false)
+		pdf.Ln(4)
+	}
+
+	if len(tpl.Terms) > 0 {
+		r.heading("Terms")
+		for i, term := range tpl.Terms {
+			pdf.SetFont("Arial", "B", 10)
+			pdf.CellFormat(60, rowHeight, r.tr(term.Label), "1", 0, "L", false, 0, "")
+			pdf.SetFont("Arial", "", 10)
+			pdf.CellFormat(0, rowHeight, r.text("term "+strconv.Itoa(i+1), term.Value), "1", 1, "L", false, 0, "")
+		}
+		pdf.Ln(4)
+	}
+
+	if tpl.Schedule {
+		r.schedule()
+	}
+
+	for i, clause := range tpl.Clauses {
+		r.heading(fmt.Sprintf("%d. %s", i+1, r.text(fmt.Sprintf("clause %d heading", i+1), clause.Heading)))
+		pdf.SetFont("Arial", "", 10)
+		pdf.MultiCell(0, lineHeight, r.text(fmt.Sprintf("clause %d", i+1), clause.Body), "", "J", false)
+		pdf.Ln(2)
+	}
+
+	if len(tpl.Signatures) > 0 {
+		r.signatures(tpl.Signatures)
+	}
+
+	if r.err != nil {
+		return r.err
+	}
+	return pdf.Output(w)
+}
+
+func (r *renderer) heading(text string) {
+	r.pdf.SetFont("Arial", "B", 12)
+	r.pdf.CellFormat(0, 8, text, "", 1, "L", false, 0, "")
+}
+
+// schedule prints the repayment schedule table, the header row is repeated on every page the table spans
+func (r *renderer) schedule() {
+	pdf := r.pdf
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+
+	r.heading("Repayment schedule")
+	header := func() {
+		pdf.SetFont("Arial", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		for _, column := range scheduleColumns {
+			pdf.CellFormat(column.width, rowHeight, column.header, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Arial", "", 9)
+	}
+	header()
+	for _, row := range r.data.Schedule {
+		if pdf.GetY()+rowHeight > pageHeight-bottom {
+			pdf.AddPage()
+			header()
+		}
+		cells := []string{strconv.Itoa(row.Sequence), row.DueDate, row.Principal, row.Interest, row.Amount, row.Outstanding}
+		for i, cell := range cells {
+			align := "R"
+			if i < 2 {
+				align = "C"
+			}
+			pdf.CellFormat(scheduleColumns[i].width, rowHeight, cell, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+}
+
+// signatures prints the signature blocks side by side, kept together on one page
+func (r *renderer) signatures(signatures []Signature) {
+	pdf := r.pdf
+	const blockHeight = 40.0
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, _, right, bottom := pdf.GetMargins()
+	if pdf.GetY()+blockHeight > pageHeight-bottom {
+		pdf.AddPage()
+	}
+
+	width := (pageWidth - left - right) / float64(len(signatures))
+	top := pdf.GetY() + 4
+	for i, signature := range signatures {
+		x := left + float64(i)*width
+		party := r.text(fmt.Sprintf("signature %d party", i+1), signature.Party)
+		name := r.text(fmt.Sprintf("signature %d name", i+1), signature.Name)
+
+		pdf.SetXY(x, top)
+		pdf.SetFont("Arial", "B", 10)
+		pdf.CellFormat(width-6, lineHeight, party, "", 2, "L", false, 0, "")
+		pdf.Line(x, top+24, x+width-10, top+24)
+		pdf.SetXY(x, top+25)
+		pdf.SetFont("Arial", "", 9)
+		pdf.CellFormat(width-6, 5, "Name: "+name, "", 2, "L", false, 0, "")
+		pdf.CellFormat(width-6, 5, "Date:", "", 2, "L", false, 0, "")
+	}
+	pdf.SetXY(left, top+blockHeight)
+}
@@ -0,0 +1,155 @@
+package agreement_test
+
+import (
+	"bytes"
+	"loan-service/agreement"
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	"loan-service/utils/money"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func loanData(tenor int) agreement.Data {
+	issued := time.Date(2025, 6, 14, 8, 30, 0, 0, time.UTC)
+	loan := entity.Loan{
+		DBCommon:  entity.DBCommon{ID: 7, CreatedAt: issued},
+		Principal: decimal.NewFromInt(1200000),
+		Currency:  money.IDR,
+		Rate:      decimal.NewFromInt(10),
+		ROI:       decimal.NewFromInt(8),
+		Tenor:     tenor,
+		Frequency: constants.FrequencyWeekly,
+	}
+	schedule := make([]entity.Installment, 0, tenor)
+	for seq := 1; seq <= tenor; seq++ {
+		schedule = append(schedule, entity.Installment{
+			Sequence:           seq,
+			DueDate:            issued.AddDate(0, 0, 7*seq),
+			Principal:          decimal.NewFromInt(1000),
+			Interest:           decimal.NewFromInt(100),
+			Amount:             decimal.NewFromInt(1100),
+			OutstandingBalance: decimal.NewFromInt(int64(1000 * (tenor - seq))),
+		})
+	}
+	borrower := entity.User{DBCommon: entity.DBCommon{ID: 1}, Username: "borrower", Email: "borrower@example.com"}
+	return agreement.NewLoanData(loan, borrower, schedule)
+}
+
+// pageCount reads the page count of the page tree of a rendered PDF
+func pageCount(t *testing.T, pdf []byte) int {
+	match := regexp.MustCompile(`/Type /Pages\s*/Kids \[[^\]]*\]\s*/Count (\d+)`).FindSubmatch(pdf)
+	if !assert.NotNil(t, match) {
+		return 0
+	}
+	count, err := strconv.Atoi(string(match[1]))
+	assert.NoError(t, err)
+	return count
+}
+
+func TestNewLoanData(t *testing.T) {
+	data := loanData(2)
+
+	assert.Equal(t, "14 June 2025", data.Date())
+	assert.Equal(t, agreement.Party{ID: 1, Name: "borrower", Email: "borrower@example.com"}, data.Borrower)
+	assert.Equal(t, agreement.LoanTerms{
+		ID:        7,
+		Principal: "1200000.00",
+		Currency:  "IDR",
+		Rate:      "10.00",
+		ROI:       "8.00",
+		Tenor:     2,
+		Frequency: "weekly",
+	}, data.Loan)
+	assert.Equal(t, []agreement.ScheduleRow{
+		{Sequence: 1, DueDate: "2025-06-21", Principal: "1000.00", Interest: "100.00", Amount: "1100.00", Outstanding: "1000.00"},
+		{Sequence: 2, DueDate: "2025-06-28", Principal: "1000.00", Interest: "100.00", Amount: "1100.00", Outstanding: "0.00"},
+	}, data.Schedule)
+}
+
+func TestNewInvestmentData(t *testing.T) {
+	issued := time.Date(2025, 6, 20, 10, 0, 0, 0, time.UTC)
+	loan := entity.Loan{
+		DBCommon:  entity.DBCommon{ID: 7},
+		Principal: decimal.NewFromInt(1200000),
+		Currency:  money.IDR,
+		Rate:      decimal.NewFromInt(10),
+		ROI:       decimal.NewFromInt(8),
+		Tenor:     12,
+		Frequency: constants.FrequencyMonthly,
+	}
+	investment := entity.Investment{
+		DBCommon: entity.DBCommon{ID: 3, CreatedAt: issued},
+		Amount:   decimal.NewFromInt(400000),
+	}
+	investor := entity.User{DBCommon: entity.DBCommon{ID: 4}, Username: "investor", Email: "investor@example.com"}
+
+	data := agreement.NewInvestmentData(loan, investment, investor)
+
+	assert.Equal(t, "20 June 2025", data.Date())
+	assert.Equal(t, agreement.Party{ID: 4, Name: "investor", Email: "investor@example.com"}, data.Investor)
+	assert.Equal(t, agreement.InvestmentTerms{
+		ID:             3,
+		Amount:         "400000.00",
+		Share:          "33.33",
+		ExpectedReturn: "32000.00",
+	}, data.Investment)
+
+	var out bytes.Buffer
+	assert.NoError(t, agreement.RenderInvestment(&out, agreement.CurrentInvestmentVersion, data))
+	assert.Equal(t, 1, pageCount(t, out.Bytes()))
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		tenor     int
+		wantPages int
+		wantErr   string
+	}{
+		{
+			name:      "Render_ShortSchedule",
+			version:   agreement.CurrentLoanVersion,
+			tenor:     4,
+			wantPages: 1,
+		},
+		{
+			name:      "Render_LongSchedule_SpansPages",
+			version:   agreement.CurrentLoanVersion,
+			tenor:     104,
+			wantPages: 4,
+		},
+		{
+			name:    "Render_UnknownVersion",
+			version: "v0",
+			tenor:   4,
+			wantErr: `agreement: unknown template version "v0"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := agreement.RenderLoan(&out, tt.version, loanData(tt.tenor))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, bytes.HasPrefix(out.Bytes(), []byte("%PDF-")))
+			assert.Equal(t, tt.wantPages, pageCount(t, out.Bytes()))
+		})
+	}
+}
+
+func TestRender_Reproducible(t *testing.T) {
+	var first, second bytes.Buffer
+	assert.NoError(t, agreement.RenderLoan(&first, agreement.CurrentLoanVersion, loanData(12)))
+	assert.NoError(t, agreement.RenderLoan(&second, agreement.CurrentLoanVersion, loanData(12)))
+	assert.Equal(t, first.Bytes(), second.Bytes())
+}
@@ -0,0 +1,95 @@
+package agreement
+
+const (
+	// CurrentLoanVersion is the template version new loan agreements are issued with
+	CurrentLoanVersion = "v1"
+	// CurrentInvestmentVersion is the template version new investment agreements are issued with
+	CurrentInvestmentVersion = "v1"
+)
+
+var loanTemplates = map[string]Template{
+	"v1": {
+		Version: "v1",
+		Title:   "Loan Agreement No. {{.Loan.ID}}",
+		Preamble: "This loan agreement is made on {{.Date}} between {{.Borrower.Name}} (the Borrower, user ID " +
+			"{{.Borrower.ID}}) and the investors funding the loan through the loan service (the Lenders). The Borrower " +
+			"agrees to borrow and repay the principal below under the following terms.",
+		Terms: []Term{
+			{Label: "Principal", Value: "{{.Loan.Principal}} {{.Loan.Currency}}"},
+			{Label: "Interest rate", Value: "{{.Loan.Rate}}% over the whole tenor"},
+			{Label: "Return to investors", Value: "{{.Loan.ROI}}%"},
+			{Label: "Tenor", Value: "{{.Loan.Tenor}} {{.Loan.Frequency}} installments"},
+			{Label: "Borrower contact", Value: "{{.Borrower.Email}}"},
+		},
+		Schedule: true,
+		Clauses: []Clause{
+			{
+				Heading: "Disbursement",
+				Body: "The principal is disbursed to the Borrower once the loan is approved and fully funded by the " +
+					"Lenders. The repayment schedule above is indicative, the due dates are fixed from the date of " +
+					"disbursement.",
+			},
+			{
+				Heading: "Repayment",
+				Body: "The Borrower repays each installment of the schedule by its due date. A payment is applied to " +
+					"the oldest installment due first, interest before principal. The Borrower may repay early " +
+					"without penalty.",
+			},
+			{
+				Heading: "Default",
+				Body: "When an installment stays unpaid after its due date the loan may be declared in default, in " +
+					"which case the outstanding principal of {{.Loan.Currency}} becomes payable at once.",
+			},
+			{
+				Heading: "Returns to lenders",
+				Body: "Repayments are distributed to the Lenders pro rata to their investments, for a total return of " +
+					"{{.Loan.ROI}}% on the principal invested.",
+			},
+		},
+		Signatures: []Signature{
+			{Party: "Borrower", Name: "{{.Borrower.Name}}"},
+			{Party: "For the Lenders", Name: "Loan service operator"},
+		},
+	},
+}
+
+var investmentTemplates = map[string]Template{
+	"v1": {
+		Version: "v1",
+		Title:   "Investment Agreement No. {{.Investment.ID}}",
+		Preamble: "This investment agreement is made on {{.Date}} between {{.Investor.Name}} (the Investor, user ID " +
+			"{{.Investor.ID}}) and the loan service operator. The Investor funds part of loan No. {{.Loan.ID}} under " +
+			"the following terms.",
+		Terms: []Term{
+			{Label: "Loan", Value: "No. {{.Loan.ID}}, {{.Loan.Principal}} {{.Loan.Currency}}"},
+			{Label: "Tenor", Value: "{{.Loan.Tenor}} {{.Loan.Frequency}} installments"},
+			{Label: "Amount invested", Value: "{{.Investment.Amount}} {{.Loan.Currency}}"},
+			{Label: "Share of the loan", Value: "{{.Investment.Share}}%"},
+			{Label: "Return on investment", Value: "{{.Loan.ROI}}%"},
+			{Label: "Expected return", Value: "{{.Investment.ExpectedReturn}} {{.Loan.Currency}}"},
+			{Label: "Investor contact", Value: "{{.Investor.Email}}"},
+		},
+		Clauses: []Clause{
+			{
+				Heading: "Payouts",
+				Body: "Every repayment of the borrower is paid out to the investors of the loan pro rata to their " +
+					"share. The Investor receives {{.Investment.Share}}% of the principal repaid and of the return " +
+					"to investors, which is {{.Loan.ROI}}% of the principal over the whole tenor.",
+			},
+			{
+				Heading: "Expected return",
+				Body: "The expected return of {{.Investment.ExpectedReturn}} {{.Loan.Currency}} assumes the loan is " +
+					"repaid in full. It is not guaranteed, the Investor bears the risk of the borrower defaulting.",
+			},
+			{
+				Heading: "Cancellation",
+				Body: "The investment can no longer be withdrawn once the loan is fully funded. When the loan is " +
+					"cancelled or expires before disbursement the amount invested is refunded in full.",
+			},
+		},
+		Signatures: []Signature{
+			{Party: "Investor", Name: "{{.Investor.Name}}"},
+			{Party: "Loan service operator", Name: "Loan service operator"},
+		},
+	},
+}
@@ -0,0 +1,12 @@
+package entity
+
+import "loan-service/utils/constants"
+
+// Actor is the authenticated user performing an action
+type Actor struct {
+	ID   uint
+	Role constants.UserRole
+}
+
+// SystemActor performs the actions triggered by the background jobs
+var SystemActor = Actor{Role: constants.RoleSystem}
@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"loan-service/utils/constants"
+	"loan-service/utils/money"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BorrowerLoan is a loan as seen by its borrower, with the rejection reason, funding progress and
+// the next installment to pay
+type BorrowerLoan struct {
+	ID              uint                         `json:"id"`
+	CreatedAt       time.Time                    `json:"created_at"`
+	Principal       decimal.Decimal              `json:"principal"`
+	Currency        money.Currency               `json:"currency"`
+	Rate            decimal.Decimal              `json:"rate"`
+	Tenor           int                          `json:"tenor"`
+	Frequency       constants.RepaymentFrequency `json:"frequency"`
+	Status          constants.LoanStatus         `json:"status"`
+	AgreementLink   *string                      `json:"agreement_link,omitempty"`
+	RejectReason    *string                      `json:"reject_reason,omitempty"`
+	FundedAmount    decimal.Decimal              `json:"funded_amount"`
+	PercentFunded   decimal.Decimal              `json:"percent_funded" gorm:"-"`
+	NextInstallment *Installment                 `json:"next_installment,omitempty" gorm:"-"`
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "loan-service/utils/constants"
+
+// Document is the record of a file kept in the document store, the content is stored under StorageKey.
+// Documents are addressed by the SHA-256 of their content, so identical files share the stored content.
+type Document struct {
+	DBCommon
+	Kind       constants.DocumentKind `json:"kind"`
+	Name       string                 `json:"name"`
+	StorageKey string                 `json:"-"`
+	MimeType   string                 `json:"mime_type"`
+	Size       int64                  `json:"size"`
+	SHA256     string                 `gorm:"column:sha256" json:"sha256"`
+	// OwnerID is the user the document was issued to or uploaded by
+	OwnerID uint  `json:"owner_id"`
+	LoanID  *uint `json:"loan_id,omitempty"`
+}
@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"loan-service/utils/constants"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Installment struct {
+	DBCommon
+	LoanID             uint                        `json:"loan_id"`
+	Sequence           int                         `json:"sequence"`
+	DueDate            time.Time                   `json:"due_date"`
+	Principal          decimal.Decimal             `gorm:"type:numeric" json:"principal"`
+	Interest           decimal.Decimal             `gorm:"type:numeric" json:"interest"`
+	Amount             decimal.Decimal             `gorm:"type:numeric" json:"amount"`
+	OutstandingBalance decimal.Decimal             `gorm:"type:numeric" json:"outstanding_balance"`
+	PaidPrincipal      decimal.Decimal             `gorm:"type:numeric" json:"paid_principal"`
+	PaidInterest       decimal.Decimal             `gorm:"type:numeric" json:"paid_interest"`
+	Status             constants.InstallmentStatus `json:"status"`
+	PaidAt             *time.Time                  `json:"paid_at,omitempty"`
+}
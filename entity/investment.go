@@ -1,8 +1,21 @@
 package entity
 
+import (
+	"loan-service/utils/constants"
+	"loan-service/utils/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// Investment is always in the currency of its loan. It is voided when the loan is cancelled before it is disbursed, the amount is refunded to the investor
 type Investment struct {
 	DBCommon
-	LoanID     uint    `json:"loan_id"`
-	InvestorID uint    `json:"investor_id"`
-	Amount     float64 `json:"amount"`
+	LoanID     uint                       `json:"loan_id"`
+	InvestorID uint                       `json:"investor_id"`
+	Amount     decimal.Decimal            `gorm:"type:numeric" json:"amount"`
+	Currency   money.Currency             `json:"currency"`
+	Status     constants.InvestmentStatus `json:"status"`
+	// AgreementLink is the agreement of the investor, issued once the loan is fully funded
+	AgreementLink    *string `json:"agreement_link,omitempty"`
+	AgreementVersion string  `json:"agreement_version,omitempty"`
 }
@@ -2,36 +2,93 @@ package entity
 
 import (
 	"loan-service/utils/constants"
+	"loan-service/utils/money"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type Loan struct {
 	DBCommon
-	BorrowerID    uint                 `json:"borrower_id"`
-	Principal     float64              `json:"principal"`
-	Rate          float64              `json:"rate"`
-	ROI           float64              `json:"roi"`
-	Status        constants.LoanStatus `json:"status"`
-	AgreementLink *string              `json:"agreement_link,omitempty"`
+	BorrowerID    uint                         `json:"borrower_id"`
+	Principal     decimal.Decimal              `gorm:"type:numeric" json:"principal"`
+	Currency      money.Currency               `json:"currency"`
+	Rate          decimal.Decimal              `gorm:"type:numeric" json:"rate"`
+	ROI           decimal.Decimal              `gorm:"type:numeric" json:"roi"`
+	Tenor         int                          `json:"tenor"`
+	Frequency     constants.RepaymentFrequency `json:"frequency"`
+	Status        constants.LoanStatus         `json:"status"`
+	AgreementLink *string                      `json:"agreement_link,omitempty"`
+	// AgreementVersion is the template version the agreement was rendered with
+	AgreementVersion string `json:"agreement_version,omitempty"`
+	// MinTicket and MaxTicket override the default investment ticket sizes of the loan currency
+	MinTicket *decimal.Decimal `gorm:"type:numeric" json:"min_ticket,omitempty"`
+	MaxTicket *decimal.Decimal `gorm:"type:numeric" json:"max_ticket,omitempty"`
+	// ResubmissionOf is the rejected loan this loan was resubmitted for, a loan is resubmitted at most once
+	ResubmissionOf *uint             `gorm:"uniqueIndex" json:"resubmission_of,omitempty"`
+	Resubmission   *LoanResubmission `gorm:"-" json:"resubmission,omitempty"`
 
 	ApprovedInfo     *LoanApproval     `gorm:"foreignKey:LoanID" json:"approved_info,omitempty"`
 	DisbursementInfo *LoanDisbursement `gorm:"foreignKey:LoanID" json:"disbursement_info,omitempty"`
-	Investments      []Investment      `gorm:"foreignKey:LoanID" json:"investments",omitempty`
+	Investments      []Investment      `gorm:"foreignKey:LoanID" json:"investments"`
+}
+
+// LoanResubmission shows the validator why the previous loan was rejected and which terms changed since
+type LoanResubmission struct {
+	PreviousLoanID uint         `json:"previous_loan_id"`
+	RejectReason   *string      `json:"reject_reason,omitempty"`
+	Changes        []TermChange `json:"changes"`
+}
+
+// TermChange is a loan term that differs between a resubmitted loan and the rejected one
+type TermChange struct {
+	Field    string `json:"field"`
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
 }
 
 type LoanApproval struct {
 	DBCommon
-	LoanID       uint      `json:"loan_id"`
-	ValidatorID  uint      `json:"validator_id"`
-	RejectReason *string   `json:"reject_reason,omitempty"`
-	PhotoURL     string    `json:"photo_url"`
-	ApprovedAt   time.Time `json:"approved_at"`
+	LoanID       uint    `json:"loan_id"`
+	ValidatorID  uint    `json:"validator_id"`
+	RejectReason *string `json:"reject_reason,omitempty"`
+	PhotoURL     string  `json:"photo_url"`
+	// PhotoDocumentID is the uploaded approval photo, PhotoURL its link
+	PhotoDocumentID *uint     `json:"photo_document_id,omitempty"`
+	ApprovedAt      time.Time `json:"approved_at"`
+	// RiskScore and ScoredRiskGrade are computed by the risk scorer, RiskGrade is the grade shown to investors and only
+	// differs from the scored grade when the validator overrides it with a justification
+	RiskScore              *int                `json:"risk_score,omitempty"`
+	ScoredRiskGrade        constants.RiskGrade `json:"scored_risk_grade,omitempty"`
+	RiskGrade              constants.RiskGrade `json:"risk_grade,omitempty"`
+	RiskGradeJustification *string             `json:"risk_grade_justification,omitempty"`
+	// Pending is set while a loan that needs more than one approval is still waiting for sign-offs, Signoffs are the
+	// sign-offs recorded so far
+	Pending  bool              `gorm:"-" json:"pending,omitempty"`
+	Signoffs []ApprovalSignoff `gorm:"-" json:"signoffs,omitempty"`
+}
+
+// ApprovalSignoff is the sign-off of one approver on a loan that needs more than one approval
+type ApprovalSignoff struct {
+	DBCommon
+	LoanID     uint               `json:"loan_id"`
+	ApproverID uint               `json:"approver_id"`
+	Role       constants.UserRole `json:"role"`
+	// PhotoDocumentID, RiskGrade, RiskGradeJustification and the ticket sizes are what the approver submitted with
+	// the sign-off, every approver of the loan has to agree on the risk grade and the ticket sizes
+	PhotoDocumentID        *uint               `json:"photo_document_id,omitempty"`
+	RiskGrade              constants.RiskGrade `json:"risk_grade"`
+	RiskGradeJustification *string             `json:"risk_grade_justification,omitempty"`
+	MinTicket              *decimal.Decimal    `gorm:"type:numeric" json:"min_ticket,omitempty"`
+	MaxTicket              *decimal.Decimal    `gorm:"type:numeric" json:"max_ticket,omitempty"`
 }
 
 type LoanDisbursement struct {
 	DBCommon
-	LoanID             uint      `json:"loan_id"`
-	SignedAgreementURL string    `json:"signed_agreement_url"`
-	DisburserID        uint      `json:"disburser_id"`
-	DisbursedAt        time.Time `json:"disbursed_at"`
+	LoanID             uint   `json:"loan_id"`
+	SignedAgreementURL string `json:"signed_agreement_url"`
+	// SignedAgreementDocumentID is the uploaded signed agreement, SignedAgreementURL its link
+	SignedAgreementDocumentID *uint     `json:"signed_agreement_document_id,omitempty"`
+	DisburserID               uint      `json:"disburser_id"`
+	DisbursedAt               time.Time `json:"disbursed_at"`
 }
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"encoding/json"
+	"loan-service/utils/constants"
+)
+
+// LoanEvent is the audit record of a loan status transition, CreatedAt is the time of the transition
+type LoanEvent struct {
+	DBCommon
+	LoanID     uint                 `json:"loan_id"`
+	Event      string               `json:"event"`
+	FromStatus constants.LoanStatus `json:"from_status"`
+	ToStatus   constants.LoanStatus `json:"to_status"`
+	ActorID    uint                 `json:"actor_id"`
+	ActorRole  constants.UserRole   `json:"actor_role"`
+	RequestID  string               `json:"request_id"`
+	Payload    json.RawMessage      `gorm:"type:jsonb" json:"payload"`
+}
@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"loan-service/utils/constants"
+	"loan-service/utils/money"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MarketplaceLoan is an approved loan open for investment along with its funding progress,
+// funded amount and investor count are aggregated from the investments table
+type MarketplaceLoan struct {
+	ID              uint                         `json:"id"`
+	CreatedAt       time.Time                    `json:"created_at"`
+	BorrowerID      uint                         `json:"borrower_id"`
+	Principal       decimal.Decimal              `json:"principal"`
+	Currency        money.Currency               `json:"currency"`
+	ROI             decimal.Decimal              `json:"roi"`
+	Tenor           int                          `json:"tenor"`
+	Frequency       constants.RepaymentFrequency `json:"frequency"`
+	Status          constants.LoanStatus         `json:"status"`
+	AgreementLink   *string                      `json:"agreement_link,omitempty"`
+	RiskGrade       constants.RiskGrade          `json:"risk_grade,omitempty"`
+	FundedAmount    decimal.Decimal              `json:"funded_amount"`
+	RemainingAmount decimal.Decimal              `json:"remaining_amount" gorm:"-"`
+	PercentFunded   decimal.Decimal              `json:"percent_funded" gorm:"-"`
+	InvestorCount   int                          `json:"investor_count"`
+	ExpectedReturn  decimal.Decimal              `json:"expected_return" gorm:"-"`
+}
@@ -0,0 +1,8 @@
+package entity
+
+import "github.com/shopspring/decimal"
+
+func init() {
+	// money is serialized as a JSON number written from the exact decimal, so no precision is lost on the way out
+	decimal.MarshalJSONWithoutQuotes = true
+}
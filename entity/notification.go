@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"loan-service/utils/constants"
+	"time"
+)
+
+type Notification struct {
+	DBCommon
+	Kind      constants.NotificationKind   `json:"kind"`
+	LoanID    uint                         `json:"loan_id"`
+	UserID    uint                         `json:"user_id"`
+	Recipient string                       `json:"recipient"`
+	Subject   string                       `json:"subject"`
+	Body      string                       `json:"body"`
+	Status    constants.NotificationStatus `json:"status"`
+	Attempts  int                          `json:"attempts"`
+	LastError *string                      `json:"last_error,omitempty"`
+	SentAt    *time.Time                   `json:"sent_at,omitempty"`
+}
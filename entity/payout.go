@@ -0,0 +1,14 @@
+package entity
+
+import "github.com/shopspring/decimal"
+
+type Payout struct {
+	DBCommon
+	RepaymentID  uint            `json:"repayment_id"`
+	LoanID       uint            `json:"loan_id"`
+	InvestmentID uint            `json:"investment_id"`
+	InvestorID   uint            `json:"investor_id"`
+	Principal    decimal.Decimal `gorm:"type:numeric" json:"principal"`
+	Return       decimal.Decimal `gorm:"type:numeric" json:"return"`
+	Amount       decimal.Decimal `gorm:"type:numeric" json:"amount"`
+}
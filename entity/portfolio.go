@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"loan-service/utils/constants"
+	"loan-service/utils/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// PortfolioLoan summarises every investment of an investor in a single loan
+type PortfolioLoan struct {
+	LoanID              uint                 `json:"loan_id"`
+	Status              constants.LoanStatus `json:"status"`
+	Currency            money.Currency       `json:"currency"`
+	ROI                 decimal.Decimal      `json:"roi"`
+	AmountInvested      decimal.Decimal      `json:"amount_invested"`
+	ExpectedReturn      decimal.Decimal      `json:"expected_return"`
+	PrincipalReceived   decimal.Decimal      `json:"principal_received"`
+	ReturnReceived      decimal.Decimal      `json:"return_received"`
+	PayoutsReceived     decimal.Decimal      `json:"payouts_received"`
+	OutstandingExposure decimal.Decimal      `json:"outstanding_exposure"`
+}
+
+// PortfolioTotal sums the portfolio loans of a single currency, amounts in different currencies are never added up
+type PortfolioTotal struct {
+	Currency                 money.Currency  `json:"currency"`
+	TotalInvested            decimal.Decimal `json:"total_invested"`
+	TotalExpectedReturn      decimal.Decimal `json:"total_expected_return"`
+	TotalPayoutsReceived     decimal.Decimal `json:"total_payouts_received"`
+	TotalOutstandingExposure decimal.Decimal `json:"total_outstanding_exposure"`
+}
+
+type Portfolio struct {
+	Loans  []PortfolioLoan  `json:"loans"`
+	Totals []PortfolioTotal `json:"totals"`
+}
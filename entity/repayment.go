@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Repayment struct {
+	DBCommon
+	LoanID      uint            `json:"loan_id"`
+	PayerID     uint            `json:"payer_id"`
+	Amount      decimal.Decimal `gorm:"type:numeric" json:"amount"`
+	Principal   decimal.Decimal `gorm:"type:numeric" json:"principal"`
+	Interest    decimal.Decimal `gorm:"type:numeric" json:"interest"`
+	PlatformFee decimal.Decimal `gorm:"type:numeric" json:"platform_fee"`
+	PaidAt      time.Time       `json:"paid_at"`
+
+	Allocations []RepaymentAllocation `gorm:"foreignKey:RepaymentID" json:"allocations,omitempty"`
+	Payouts     []Payout              `gorm:"foreignKey:RepaymentID" json:"payouts,omitempty"`
+}
+
+type RepaymentAllocation struct {
+	DBCommon
+	RepaymentID   uint            `json:"repayment_id"`
+	InstallmentID uint            `json:"installment_id"`
+	Principal     decimal.Decimal `gorm:"type:numeric" json:"principal"`
+	Interest      decimal.Decimal `gorm:"type:numeric" json:"interest"`
+}
@@ -1,18 +1,41 @@
 package entity
 
+import (
+	"loan-service/utils/constants"
+	"loan-service/utils/money"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
 type RequestSignin struct {
 	Username string `json:"username" binding:"required"`
 }
 
 type RequestProposeLoan struct {
-	Principal float64 `json:"principal" binding:"required"`
-	Rate      float64 `json:"rate" binding:"required"`
-	ROI       float64 `json:"roi" binding:"required"`
+	Principal decimal.Decimal              `json:"principal" binding:"required"`
+	Rate      decimal.Decimal              `json:"rate" binding:"required"`
+	ROI       decimal.Decimal              `json:"roi" binding:"required"`
+	Tenor     int                          `json:"tenor,omitempty" binding:"omitempty,min=1"`
+	Frequency constants.RepaymentFrequency `json:"frequency,omitempty" binding:"omitempty,oneof=weekly monthly"`
+	Currency  money.Currency               `json:"currency,omitempty"`
+}
+
+// RequestResubmitLoan proposes the amended terms of a rejected loan
+type RequestResubmitLoan struct {
+	LoanID uint `json:"loan_id" binding:"required"`
+	RequestProposeLoan
 }
 
 type RequestApproveLoan struct {
-	LoanID   uint   `json:"loan_id" binding:"required"`
-	PhotoURL string `json:"photo_url" binding:"required"`
+	LoanID uint `json:"loan_id" binding:"required"`
+	// PhotoDocumentID is the approval photo uploaded for the loan
+	PhotoDocumentID uint             `json:"photo_document_id" binding:"required"`
+	MinTicket       *decimal.Decimal `json:"min_ticket,omitempty"`
+	MaxTicket       *decimal.Decimal `json:"max_ticket,omitempty"`
+	// RiskGrade overrides the scored grade of the loan, a justification is required
+	RiskGrade              constants.RiskGrade `json:"risk_grade,omitempty"`
+	RiskGradeJustification string              `json:"risk_grade_justification,omitempty"`
 }
 
 type RequestRejectLoan struct {
@@ -21,11 +44,63 @@ type RequestRejectLoan struct {
 }
 
 type RequestAddInvestment struct {
-	LoanID uint    `json:"loan_id" binding:"required"`
-	Amount float64 `json:"amount" binding:"required"`
+	LoanID   uint            `json:"loan_id" binding:"required"`
+	Amount   decimal.Decimal `json:"amount" binding:"required"`
+	Currency money.Currency  `json:"currency,omitempty"`
 }
 
 type RequestDisburseLoan struct {
-	LoanID             uint   `json:"loan_id" binding:"required"`
-	SignedAgreementURL string `json:"signed_agreement_url" binding:"required"`
+	LoanID uint `json:"loan_id" binding:"required"`
+	// SignedAgreementDocumentID is the signed agreement uploaded for the loan
+	SignedAgreementDocumentID uint `json:"signed_agreement_document_id" binding:"required"`
+}
+
+// RequestUploadDocument is the form of an upload, the kind is set by the endpoint
+type RequestUploadDocument struct {
+	LoanID uint                   `form:"loan_id" binding:"required"`
+	Kind   constants.DocumentKind `form:"-"`
+}
+
+type RequestAddRepayment struct {
+	LoanID uint            `json:"-"`
+	Amount decimal.Decimal `json:"amount" binding:"required"`
+}
+
+type RequestDefaultLoan struct {
+	LoanID uint `json:"loan_id" binding:"required"`
+}
+
+type RequestCancelInvestment struct {
+	InvestmentID uint `json:"investment_id" binding:"required"`
+}
+
+type RequestCancelLoan struct {
+	LoanID uint   `json:"loan_id" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+type RequestListLoans struct {
+	Status       []constants.LoanStatus `form:"status" binding:"omitempty,dive,oneof=proposed rejected approved invested disbursed repaying paid_off defaulted cancelled expired"`
+	BorrowerID   uint                   `form:"borrower_id"`
+	Currency     money.Currency         `form:"currency"`
+	MinPrincipal *decimal.Decimal       `form:"min_principal"`
+	MaxPrincipal *decimal.Decimal       `form:"max_principal"`
+	CreatedFrom  *time.Time             `form:"created_from"`
+	CreatedTo    *time.Time             `form:"created_to"`
+	SortBy       string                 `form:"sort_by" binding:"omitempty,oneof=created_at principal"`
+	Order        string                 `form:"order" binding:"omitempty,oneof=asc desc"`
+	Limit        int                    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor       string                 `form:"cursor"`
+	// InvestorID limits the loans to the approved ones and the ones the investor invested in, it is set from the caller
+	InvestorID uint `form:"-"`
+}
+
+type RequestMarketplace struct {
+	Currency     money.Currency   `form:"currency"`
+	MinPrincipal *decimal.Decimal `form:"min_principal"`
+	MaxPrincipal *decimal.Decimal `form:"max_principal"`
+	SortBy       string           `form:"sort_by" binding:"omitempty,oneof=created_at principal"`
+	Order        string           `form:"order" binding:"omitempty,oneof=asc desc"`
+	Limit        int              `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor       string           `form:"cursor"`
 }
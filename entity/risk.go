@@ -0,0 +1,17 @@
+package entity
+
+import "loan-service/utils/constants"
+
+// BorrowerHistory summarizes the earlier loans of a borrower for the risk scorer, the loan being scored is not counted
+type BorrowerHistory struct {
+	PaidOffLoans   int
+	DefaultedLoans int
+	ActiveLoans    int
+	RejectedLoans  int
+}
+
+// RiskAssessment is the outcome of scoring a loan, the score goes from 0 (riskiest) to 100
+type RiskAssessment struct {
+	Score int
+	Grade constants.RiskGrade
+}
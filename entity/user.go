@@ -3,5 +3,6 @@ package entity
 type User struct {
 	DBCommon
 	Username string `gorm:"uniqueIndex" json:"username"`
+	Email    string `json:"email,omitempty"`
 	Role     uint   `json:"role"`
 }
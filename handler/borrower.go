@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BorrowerHandler struct {
+	borrowerUsecase BorrowerUsecaseInterface
+	userUsecase     UserUsecaseInterface
+}
+
+func RegisterBorrowerHandler(r *gin.RouterGroup, borrowerUsecase BorrowerUsecaseInterface, userUsecase UserUsecaseInterface) {
+	h := &BorrowerHandler{borrowerUsecase: borrowerUsecase, userUsecase: userUsecase}
+	g := r.Group("/borrowers", authMiddleware())
+
+	g.GET("/me/loans", h.getLoans)
+}
+
+func (h *BorrowerHandler) getLoans(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	if !verifyUserRole(h.userUsecase, userID, constants.RoleBorrower) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	loans, err := h.borrowerUsecase.GetLoans(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": loans})
+}
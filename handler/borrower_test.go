@@ -0,0 +1,138 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"loan-service/entity"
+	"loan-service/handler"
+	"loan-service/handler/mocks"
+	"loan-service/utils/auth"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBorrowerLoans(t *testing.T) {
+	rejectReason := "Incomplete documents"
+	tests := []struct {
+		name           string
+		mockFunc       func(mockBorrowerUsecase *mocks.BorrowerUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface)
+		expectStatus   int
+		expectResponse handler.Response
+	}{
+		{
+			name: "Success",
+			mockFunc: func(mockBorrowerUsecase *mocks.BorrowerUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleBorrower, nil)
+				mockBorrowerUsecase.On("GetLoans", uint(1)).Return([]entity.BorrowerLoan{
+					{
+						ID:            2,
+						Principal:     decimal.NewFromInt(400),
+						Rate:          decimal.NewFromInt(10),
+						Tenor:         12,
+						Frequency:     constants.FrequencyMonthly,
+						Status:        constants.StatusApproved,
+						FundedAmount:  decimal.NewFromInt(100),
+						PercentFunded: decimal.NewFromInt(25),
+					},
+					{
+						ID:           1,
+						Principal:    decimal.NewFromInt(500),
+						Status:       constants.StatusRejected,
+						RejectReason: &rejectReason,
+					},
+				}, nil)
+			},
+			expectStatus: http.StatusOK,
+			expectResponse: handler.Response{
+				Data: []interface{}{
+					map[string]interface{}{
+						"id":             float64(2),
+						"created_at":     "0001-01-01T00:00:00Z",
+						"principal":      float64(400),
+						"currency":       "",
+						"rate":           float64(10),
+						"tenor":          float64(12),
+						"frequency":      string(constants.FrequencyMonthly),
+						"status":         string(constants.StatusApproved),
+						"funded_amount":  float64(100),
+						"percent_funded": float64(25),
+					},
+					map[string]interface{}{
+						"id":             float64(1),
+						"created_at":     "0001-01-01T00:00:00Z",
+						"principal":      float64(500),
+						"currency":       "",
+						"rate":           float64(0),
+						"tenor":          float64(0),
+						"frequency":      "",
+						"status":         string(constants.StatusRejected),
+						"reject_reason":  rejectReason,
+						"funded_amount":  float64(0),
+						"percent_funded": float64(0),
+					},
+				},
+			},
+		},
+		{
+			name: "Wrong role",
+			mockFunc: func(mockBorrowerUsecase *mocks.BorrowerUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleInvestor, nil)
+			},
+			expectStatus: http.StatusForbidden,
+			expectResponse: handler.Response{
+				Error: errs.ErrUnauthorizedAction,
+			},
+		},
+		{
+			name: "GetLoans error",
+			mockFunc: func(mockBorrowerUsecase *mocks.BorrowerUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleBorrower, nil)
+				mockBorrowerUsecase.On("GetLoans", uint(1)).Return(nil, fmt.Errorf("error fetching loans"))
+			},
+			expectStatus: http.StatusInternalServerError,
+			expectResponse: handler.Response{
+				Error: "error fetching loans",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			mockBorrowerUsecase := mocks.NewBorrowerUsecaseInterface(t)
+			mockUserUsecase := mocks.NewUserUsecaseInterface(t)
+			auth.StartAuthorizer("test-secret")
+
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockBorrowerUsecase, mockUserUsecase)
+			}
+
+			router := gin.Default()
+			handler.RegisterBorrowerHandler(router.Group("/api"), mockBorrowerUsecase, mockUserUsecase)
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/borrowers/me/loans", nil)
+			token, _ := auth.GenerateToken("testuser", 1)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectStatus, resp.Code)
+			var response handler.Response
+			err := json.Unmarshal(resp.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if tt.expectStatus == http.StatusOK {
+				assert.Equal(t, tt.expectResponse.Data, response.Data)
+			} else {
+				assert.Equal(t, tt.expectResponse.Error, response.Error)
+			}
+		})
+	}
+}
@@ -1,36 +1,84 @@
 package handler
 
 import (
+	"errors"
+	"loan-service/entity"
 	"loan-service/utils/auth"
 	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
 	"loan-service/utils/logger"
+	"loan-service/utils/requestid"
 	"net/http"
+	"reflect"
+	"slices"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
+func init() {
+	// binding tags validate decimal amounts by their value, so a zero amount fails required like a zero float did
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+			return field.Interface().(decimal.Decimal).InexactFloat64()
+		}, decimal.Decimal{})
+	}
+}
+
 type Response struct {
-	Data  interface{} `json:"data,omitempty"`
-	Error string      `json:"error,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Error      string      `json:"error,omitempty"`
 }
 
-func (h *LoanHandler) verifyUserRole(userID uint, expectedRole constants.UserRole) bool {
-	role, err := h.userUsecase.GetUserRole(userID)
+func verifyUserRole(userUsecase UserUsecaseInterface, userID uint, expectedRole constants.UserRole) bool {
+	_, ok := authorizeUser(userUsecase, userID, expectedRole)
+	return ok
+}
+
+// authorizeUser checks the role of the user like verifyUserRole and returns the user as the actor of the action,
+// the user may have any of the expected roles
+func authorizeUser(userUsecase UserUsecaseInterface, userID uint, expectedRoles ...constants.UserRole) (entity.Actor, bool) {
+	role, err := userUsecase.GetUserRole(userID)
 	if err != nil {
 		logger.Error("Failed to get user role", zap.Uint("userID", userID), zap.Error(err))
-		return false
+		return entity.Actor{}, false
 	}
 
+	actor := entity.Actor{ID: userID, Role: role}
 	if role == constants.RoleAdmin {
-		return true
+		return actor, true
 	}
 
-	if role != expectedRole {
+	if !slices.Contains(expectedRoles, role) {
 		logger.Error("Unauthorized action for user role", zap.Uint("userID", userID), zap.String("role", string(role)))
-		return false
+		return entity.Actor{}, false
+	}
+	return actor, true
+}
+
+// isLimitExceeded reports whether the action was refused because of a risk limit, its message holds the current exposure
+func isLimitExceeded(err error) bool {
+	var limitErr *errs.LimitExceededError
+	return errors.As(err, &limitErr)
+}
+
+// RequestIDMiddleware tags every request with an ID, it is returned in the response header and
+// stored with the loan events triggered by the request
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if id == "" {
+			id = requestid.New()
+		}
+		c.Set(requestid.ContextKey, id)
+		c.Header(requestid.Header, id)
+
+		c.Next()
 	}
-	return true
 }
 
 func authMiddleware() gin.HandlerFunc {
@@ -0,0 +1,54 @@
+package handler_test
+
+import (
+	"loan-service/handler"
+	"loan-service/utils/requestid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+	}{
+		{
+			name:     "Keeps incoming request ID",
+			incoming: "req-123",
+		},
+		{
+			name: "Generates request ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(handler.RequestIDMiddleware())
+
+			var seen string
+			router.GET("/ping", func(c *gin.Context) {
+				seen = requestid.FromContext(c)
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestid.Header, tt.incoming)
+			}
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.NotEmpty(t, seen)
+			assert.Equal(t, seen, resp.Header().Get(requestid.Header))
+			if tt.incoming != "" {
+				assert.Equal(t, tt.incoming, seen)
+			}
+		})
+	}
+}
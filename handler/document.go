@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is allowed on top of the upload size limit for the form fields and part headers
+const multipartOverhead = 64 << 10
+
+type DocumentHandler struct {
+	documentUsecase DocumentUsecaseInterface
+	userUsecase     UserUsecaseInterface
+	maxUploadSize   int64
+}
+
+func RegisterDocumentHandler(r *gin.RouterGroup, documentUsecase DocumentUsecaseInterface, userUsecase UserUsecaseInterface, maxUploadSize int64) {
+	h := &DocumentHandler{documentUsecase: documentUsecase, userUsecase: userUsecase, maxUploadSize: maxUploadSize}
+	g := r.Group("/documents", authMiddleware())
+
+	g.GET("/:id", h.getDocument)
+	g.POST("/approval-photos", h.uploadApprovalPhoto)
+	g.POST("/signed-agreements", h.uploadSignedAgreement)
+}
+
+// getDocument streams the document content, who may read it is decided by the usecase
+func (h *DocumentHandler) getDocument(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	role, err := h.userUsecase.GetUserRole(userID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	documentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errs.ErrDocumentNotFound})
+		return
+	}
+
+	document, content, err := h.documentUsecase.OpenDocument(c.Request.Context(), uint(documentID), entity.Actor{ID: userID, Role: role})
+	if err != nil {
+		switch err.Error() {
+		case errs.ErrDocumentNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errs.ErrUnauthorizedAction:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, document.Size, document.MimeType, content, map[string]string{
+		"Content-Disposition": fmt.Sprintf("inline; filename=%q", document.Name),
+		"ETag":                fmt.Sprintf("%q", document.SHA256),
+	})
+}
+
+func (h *DocumentHandler) uploadApprovalPhoto(c *gin.Context) {
+	h.upload(c, constants.DocumentApprovalPhoto, constants.RoleValidator, constants.RoleSupervisor)
+}
+
+func (h *DocumentHandler) uploadSignedAgreement(c *gin.Context) {
+	h.upload(c, constants.DocumentSignedAgreement, constants.RoleDisburser)
+}
+
+// upload takes the multipart form with the loan_id and the file, the request body is capped so an oversized upload is
+// refused without being read in full
+func (h *DocumentHandler) upload(c *gin.Context, kind constants.DocumentKind, roles ...constants.UserRole) {
+	userID := c.MustGet("userID").(uint)
+	actor, ok := authorizeUser(h.userUsecase, userID, roles...)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadSize+multipartOverhead)
+	var input entity.RequestUploadDocument
+	if err := c.ShouldBind(&input); err != nil {
+		h.uploadError(c, err)
+		return
+	}
+	input.Kind = kind
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		h.uploadError(c, err)
+		return
+	}
+	if header.Size > h.maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errs.ErrDocumentTooLarge})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	body, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	document, err := h.documentUsecase.UploadDocument(c.Request.Context(), input, body, actor)
+	if err != nil {
+		switch err.Error() {
+		case errs.ErrLoanNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errs.ErrUnsupportedDocument:
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": document})
+}
+
+func (h *DocumentHandler) uploadError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errs.ErrDocumentTooLarge})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
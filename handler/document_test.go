@@ -0,0 +1,309 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"loan-service/entity"
+	"loan-service/handler"
+	"loan-service/handler/mocks"
+	"loan-service/utils/auth"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetDocument(t *testing.T) {
+	body := "%PDF-1.3 agreement"
+	document := &entity.Document{
+		DBCommon: entity.DBCommon{ID: 7},
+		Kind:     constants.DocumentLoanAgreement,
+		Name:     "loan_agreement_1.pdf",
+		MimeType: "application/pdf",
+		Size:     int64(len(body)),
+		SHA256:   "abcdef",
+		OwnerID:  1,
+	}
+	tests := []struct {
+		name         string
+		documentID   string
+		mockFunc     func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface)
+		expectStatus int
+		expectError  string
+	}{
+		{
+			name:       "Success",
+			documentID: "7",
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleBorrower, nil)
+				mockDocumentUsecase.On("OpenDocument", mock.Anything, uint(7), entity.Actor{ID: 1, Role: constants.RoleBorrower}).
+					Return(document, io.NopCloser(strings.NewReader(body)), nil)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:       "Invalid document ID",
+			documentID: "abc",
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleBorrower, nil)
+			},
+			expectStatus: http.StatusNotFound,
+			expectError:  errs.ErrDocumentNotFound,
+		},
+		{
+			name:       "Unknown user",
+			documentID: "7",
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.UserRole(""), errors.New(errs.ErrUserNotFound))
+			},
+			expectStatus: http.StatusForbidden,
+			expectError:  errs.ErrUnauthorizedAction,
+		},
+		{
+			name:       "Document not found",
+			documentID: "7",
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleBorrower, nil)
+				mockDocumentUsecase.On("OpenDocument", mock.Anything, uint(7), entity.Actor{ID: 1, Role: constants.RoleBorrower}).
+					Return(nil, nil, errors.New(errs.ErrDocumentNotFound))
+			},
+			expectStatus: http.StatusNotFound,
+			expectError:  errs.ErrDocumentNotFound,
+		},
+		{
+			name:       "Not allowed to read the document",
+			documentID: "7",
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleInvestor, nil)
+				mockDocumentUsecase.On("OpenDocument", mock.Anything, uint(7), entity.Actor{ID: 1, Role: constants.RoleInvestor}).
+					Return(nil, nil, errors.New(errs.ErrUnauthorizedAction))
+			},
+			expectStatus: http.StatusForbidden,
+			expectError:  errs.ErrUnauthorizedAction,
+		},
+		{
+			name:       "Store error",
+			documentID: "7",
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleBorrower, nil)
+				mockDocumentUsecase.On("OpenDocument", mock.Anything, uint(7), entity.Actor{ID: 1, Role: constants.RoleBorrower}).
+					Return(nil, nil, errors.New("storage unavailable"))
+			},
+			expectStatus: http.StatusInternalServerError,
+			expectError:  "storage unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			mockDocumentUsecase := mocks.NewDocumentUsecaseInterface(t)
+			mockUserUsecase := mocks.NewUserUsecaseInterface(t)
+			auth.StartAuthorizer("test-secret")
+
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockDocumentUsecase, mockUserUsecase)
+			}
+
+			router := gin.Default()
+			handler.RegisterDocumentHandler(router.Group("/api"), mockDocumentUsecase, mockUserUsecase, 1<<20)
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/documents/"+tt.documentID, nil)
+			token, _ := auth.GenerateToken("testuser", 1)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectStatus, resp.Code)
+			if tt.expectStatus == http.StatusOK {
+				assert.Equal(t, body, resp.Body.String())
+				assert.Equal(t, "application/pdf", resp.Header().Get("Content-Type"))
+				assert.Equal(t, `inline; filename="loan_agreement_1.pdf"`, resp.Header().Get("Content-Disposition"))
+				assert.Equal(t, `"abcdef"`, resp.Header().Get("ETag"))
+				return
+			}
+			var response handler.Response
+			assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectError, response.Error)
+		})
+	}
+}
+
+func TestUploadDocument(t *testing.T) {
+	const maxUploadSize = 1 << 10
+	photo := []byte("\x89PNG\r\n\x1a\n image data")
+	tests := []struct {
+		name         string
+		path         string
+		loanID       string
+		file         []byte
+		mockFunc     func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface)
+		expectStatus int
+		expectError  string
+	}{
+		{
+			name:   "Approval photo uploaded",
+			path:   "/api/documents/approval-photos",
+			loanID: "1",
+			file:   photo,
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleValidator, nil)
+				mockDocumentUsecase.On("UploadDocument", mock.Anything,
+					entity.RequestUploadDocument{LoanID: 1, Kind: constants.DocumentApprovalPhoto}, photo,
+					entity.Actor{ID: 1, Role: constants.RoleValidator}).
+					Return(&entity.Document{DBCommon: entity.DBCommon{ID: 4}, Kind: constants.DocumentApprovalPhoto, MimeType: "image/png"}, nil)
+			},
+			expectStatus: http.StatusCreated,
+		},
+		{
+			name:   "Signed agreement uploaded",
+			path:   "/api/documents/signed-agreements",
+			loanID: "1",
+			file:   photo,
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleDisburser, nil)
+				mockDocumentUsecase.On("UploadDocument", mock.Anything,
+					entity.RequestUploadDocument{LoanID: 1, Kind: constants.DocumentSignedAgreement}, photo,
+					entity.Actor{ID: 1, Role: constants.RoleDisburser}).
+					Return(&entity.Document{DBCommon: entity.DBCommon{ID: 5}, Kind: constants.DocumentSignedAgreement, MimeType: "image/png"}, nil)
+			},
+			expectStatus: http.StatusCreated,
+		},
+		{
+			name:   "Wrong role",
+			path:   "/api/documents/signed-agreements",
+			loanID: "1",
+			file:   photo,
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleValidator, nil)
+			},
+			expectStatus: http.StatusForbidden,
+			expectError:  errs.ErrUnauthorizedAction,
+		},
+		{
+			name: "Missing loan ID",
+			path: "/api/documents/approval-photos",
+			file: photo,
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleValidator, nil)
+			},
+			expectStatus: http.StatusBadRequest,
+			expectError:  "Error:Field validation",
+		},
+		{
+			name:   "Missing file",
+			path:   "/api/documents/approval-photos",
+			loanID: "1",
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleValidator, nil)
+			},
+			expectStatus: http.StatusBadRequest,
+			expectError:  http.ErrMissingFile.Error(),
+		},
+		{
+			name:   "File over the size limit",
+			path:   "/api/documents/approval-photos",
+			loanID: "1",
+			file:   bytes.Repeat([]byte{0}, maxUploadSize+1),
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleValidator, nil)
+			},
+			expectStatus: http.StatusRequestEntityTooLarge,
+			expectError:  errs.ErrDocumentTooLarge,
+		},
+		{
+			name:   "Request over the size limit",
+			path:   "/api/documents/approval-photos",
+			loanID: "1",
+			file:   bytes.Repeat([]byte{0}, 128<<10),
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleValidator, nil)
+			},
+			expectStatus: http.StatusRequestEntityTooLarge,
+			expectError:  errs.ErrDocumentTooLarge,
+		},
+		{
+			name:   "Unsupported file",
+			path:   "/api/documents/approval-photos",
+			loanID: "1",
+			file:   []byte("plain text"),
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleValidator, nil)
+				mockDocumentUsecase.On("UploadDocument", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, errors.New(errs.ErrUnsupportedDocument))
+			},
+			expectStatus: http.StatusUnsupportedMediaType,
+			expectError:  errs.ErrUnsupportedDocument,
+		},
+		{
+			name:   "Loan not found",
+			path:   "/api/documents/approval-photos",
+			loanID: "9",
+			file:   photo,
+			mockFunc: func(mockDocumentUsecase *mocks.DocumentUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleValidator, nil)
+				mockDocumentUsecase.On("UploadDocument", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, errors.New(errs.ErrLoanNotFound))
+			},
+			expectStatus: http.StatusNotFound,
+			expectError:  errs.ErrLoanNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			mockDocumentUsecase := mocks.NewDocumentUsecaseInterface(t)
+			mockUserUsecase := mocks.NewUserUsecaseInterface(t)
+			auth.StartAuthorizer("test-secret")
+
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockDocumentUsecase, mockUserUsecase)
+			}
+
+			router := gin.Default()
+			handler.RegisterDocumentHandler(router.Group("/api"), mockDocumentUsecase, mockUserUsecase, maxUploadSize)
+
+			var form bytes.Buffer
+			writer := multipart.NewWriter(&form)
+			if tt.loanID != "" {
+				assert.NoError(t, writer.WriteField("loan_id", tt.loanID))
+			}
+			if tt.file != nil {
+				part, err := writer.CreateFormFile("file", "upload.png")
+				assert.NoError(t, err)
+				_, err = part.Write(tt.file)
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, writer.Close())
+
+			req, _ := http.NewRequest(http.MethodPost, tt.path, &form)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			token, _ := auth.GenerateToken("testuser", 1)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectStatus, resp.Code)
+			var response handler.Response
+			assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			if tt.expectStatus == http.StatusCreated {
+				assert.NotNil(t, response.Data)
+			} else {
+				assert.Contains(t, response.Error, tt.expectError)
+			}
+		})
+	}
+}
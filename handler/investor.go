@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvestorHandler struct {
+	investorUsecase InvestorUsecaseInterface
+	userUsecase     UserUsecaseInterface
+}
+
+func RegisterInvestorHandler(r *gin.RouterGroup, investorUsecase InvestorUsecaseInterface, userUsecase UserUsecaseInterface) {
+	h := &InvestorHandler{investorUsecase: investorUsecase, userUsecase: userUsecase}
+	g := r.Group("/investors", authMiddleware())
+
+	g.GET("/me/payouts", h.getPayouts)
+	g.GET("/me/portfolio", h.getPortfolio)
+	g.GET("/marketplace", h.getMarketplace)
+}
+
+func (h *InvestorHandler) getPayouts(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	if !verifyUserRole(h.userUsecase, userID, constants.RoleInvestor) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	var loanID uint64
+	if raw := c.Query("loan_id"); raw != "" {
+		var err error
+		if loanID, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan_id"})
+			return
+		}
+	}
+
+	payouts, err := h.investorUsecase.GetPayouts(userID, uint(loanID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": payouts})
+}
+
+func (h *InvestorHandler) getPortfolio(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	if !verifyUserRole(h.userUsecase, userID, constants.RoleInvestor) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	portfolio, err := h.investorUsecase.GetPortfolio(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": portfolio})
+}
+
+func (h *InvestorHandler) getMarketplace(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	if !verifyUserRole(h.userUsecase, userID, constants.RoleInvestor) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	var input entity.RequestMarketplace
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loans, nextCursor, err := h.investorUsecase.GetMarketplace(input)
+	if err != nil {
+		switch err.Error() {
+		case errs.ErrInvalidLoanFilter, errs.ErrInvalidCursor:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: loans, NextCursor: nextCursor})
+}
@@ -0,0 +1,385 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"loan-service/entity"
+	"loan-service/handler"
+	"loan-service/handler/mocks"
+	"loan-service/utils/auth"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/money"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPayouts(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockFunc       func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface)
+		expectStatus   int
+		expectResponse handler.Response
+	}{
+		{
+			name: "Success",
+			mockFunc: func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleInvestor, nil)
+				mockInvestorUsecase.On("GetPayouts", uint(1), uint(0)).Return([]entity.Payout{
+					{
+						DBCommon:     entity.DBCommon{ID: 1},
+						RepaymentID:  2,
+						LoanID:       3,
+						InvestmentID: 4,
+						InvestorID:   1,
+						Principal:    decimal.NewFromInt(100),
+						Return:       decimal.NewFromInt(9),
+						Amount:       decimal.NewFromInt(109),
+					},
+				}, nil)
+			},
+			expectStatus: http.StatusOK,
+			expectResponse: handler.Response{
+				Data: []interface{}{
+					map[string]interface{}{
+						"created_at":    "0001-01-01T00:00:00Z",
+						"updated_at":    "0001-01-01T00:00:00Z",
+						"id":            float64(1),
+						"repayment_id":  float64(2),
+						"loan_id":       float64(3),
+						"investment_id": float64(4),
+						"investor_id":   float64(1),
+						"principal":     float64(100),
+						"return":        float64(9),
+						"amount":        float64(109),
+					},
+				},
+			},
+		},
+		{
+			name:  "Filter by loan",
+			query: "?loan_id=3",
+			mockFunc: func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleInvestor, nil)
+				mockInvestorUsecase.On("GetPayouts", uint(1), uint(3)).Return([]entity.Payout{}, nil)
+			},
+			expectStatus: http.StatusOK,
+			expectResponse: handler.Response{
+				Data: []interface{}{},
+			},
+		},
+		{
+			name:  "Invalid loan id",
+			query: "?loan_id=abc",
+			mockFunc: func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleInvestor, nil)
+			},
+			expectStatus: http.StatusBadRequest,
+			expectResponse: handler.Response{
+				Error: "Invalid loan_id",
+			},
+		},
+		{
+			name: "Wrong role",
+			mockFunc: func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleBorrower, nil)
+			},
+			expectStatus: http.StatusForbidden,
+			expectResponse: handler.Response{
+				Error: errs.ErrUnauthorizedAction,
+			},
+		},
+		{
+			name: "GetPayouts error",
+			mockFunc: func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleInvestor, nil)
+				mockInvestorUsecase.On("GetPayouts", uint(1), uint(0)).Return(nil, fmt.Errorf("error fetching payouts"))
+			},
+			expectStatus: http.StatusInternalServerError,
+			expectResponse: handler.Response{
+				Error: "error fetching payouts",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			mockInvestorUsecase := mocks.NewInvestorUsecaseInterface(t)
+			mockUserUsecase := mocks.NewUserUsecaseInterface(t)
+			auth.StartAuthorizer("test-secret")
+
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockInvestorUsecase, mockUserUsecase)
+			}
+
+			router := gin.Default()
+			handler.RegisterInvestorHandler(router.Group("/api"), mockInvestorUsecase, mockUserUsecase)
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/investors/me/payouts"+tt.query, nil)
+			token, _ := auth.GenerateToken("testuser", 1)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectStatus, resp.Code)
+			var response handler.Response
+			err := json.Unmarshal(resp.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if tt.expectStatus == http.StatusOK {
+				assert.Equal(t, tt.expectResponse.Data, response.Data)
+			} else {
+				assert.Equal(t, tt.expectResponse.Error, response.Error)
+			}
+		})
+	}
+}
+
+func TestGetMarketplace(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockFunc       func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface)
+		expectStatus   int
+		expectResponse handler.Response
+	}{
+		{
+			name:  "Success",
+			query: "?limit=1",
+			mockFunc: func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleInvestor, nil)
+				mockInvestorUsecase.On("GetMarketplace", entity.RequestMarketplace{Limit: 1}).Return([]entity.MarketplaceLoan{
+					{
+						ID:              4,
+						BorrowerID:      2,
+						Principal:       decimal.NewFromInt(1000),
+						ROI:             decimal.NewFromInt(8),
+						Tenor:           12,
+						Frequency:       constants.FrequencyMonthly,
+						Status:          constants.StatusApproved,
+						FundedAmount:    decimal.NewFromInt(400),
+						RemainingAmount: decimal.NewFromInt(600),
+						PercentFunded:   decimal.NewFromInt(40),
+						InvestorCount:   2,
+						ExpectedReturn:  decimal.NewFromInt(48),
+					},
+				}, "next-page", nil)
+			},
+			expectStatus: http.StatusOK,
+			expectResponse: handler.Response{
+				Data: []interface{}{
+					map[string]interface{}{
+						"id":               float64(4),
+						"created_at":       "0001-01-01T00:00:00Z",
+						"borrower_id":      float64(2),
+						"principal":        float64(1000),
+						"currency":         "",
+						"roi":              float64(8),
+						"tenor":            float64(12),
+						"frequency":        string(constants.FrequencyMonthly),
+						"status":           string(constants.StatusApproved),
+						"funded_amount":    float64(400),
+						"remaining_amount": float64(600),
+						"percent_funded":   float64(40),
+						"investor_count":   float64(2),
+						"expected_return":  float64(48),
+					},
+				},
+				NextCursor: "next-page",
+			},
+		},
+		{
+			name:  "Invalid sorting",
+			query: "?sort_by=roi",
+			mockFunc: func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleInvestor, nil)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Wrong role",
+			mockFunc: func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleBorrower, nil)
+			},
+			expectStatus: http.StatusForbidden,
+			expectResponse: handler.Response{
+				Error: errs.ErrUnauthorizedAction,
+			},
+		},
+		{
+			name: "GetMarketplace error",
+			mockFunc: func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleInvestor, nil)
+				mockInvestorUsecase.On("GetMarketplace", entity.RequestMarketplace{}).Return(nil, "", fmt.Errorf("error fetching marketplace"))
+			},
+			expectStatus: http.StatusInternalServerError,
+			expectResponse: handler.Response{
+				Error: "error fetching marketplace",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			mockInvestorUsecase := mocks.NewInvestorUsecaseInterface(t)
+			mockUserUsecase := mocks.NewUserUsecaseInterface(t)
+			auth.StartAuthorizer("test-secret")
+
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockInvestorUsecase, mockUserUsecase)
+			}
+
+			router := gin.Default()
+			handler.RegisterInvestorHandler(router.Group("/api"), mockInvestorUsecase, mockUserUsecase)
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/investors/marketplace"+tt.query, nil)
+			token, _ := auth.GenerateToken("testuser", 1)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectStatus, resp.Code)
+			var response handler.Response
+			err := json.Unmarshal(resp.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if tt.expectStatus == http.StatusOK {
+				assert.Equal(t, tt.expectResponse.Data, response.Data)
+				assert.Equal(t, tt.expectResponse.NextCursor, response.NextCursor)
+			} else if tt.expectResponse.Error != "" {
+				assert.Equal(t, tt.expectResponse.Error, response.Error)
+			}
+		})
+	}
+}
+
+func TestGetPortfolio(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockFunc       func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface)
+		expectStatus   int
+		expectResponse handler.Response
+	}{
+		{
+			name: "Success",
+			mockFunc: func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleInvestor, nil)
+				mockInvestorUsecase.On("GetPortfolio", uint(1)).Return(&entity.Portfolio{
+					Loans: []entity.PortfolioLoan{
+						{
+							LoanID:              1,
+							Status:              constants.StatusRepaying,
+							Currency:            money.IDR,
+							ROI:                 decimal.NewFromInt(8),
+							AmountInvested:      decimal.NewFromInt(600),
+							ExpectedReturn:      decimal.NewFromInt(48),
+							PrincipalReceived:   decimal.NewFromInt(100),
+							ReturnReceived:      decimal.NewFromInt(8),
+							PayoutsReceived:     decimal.NewFromInt(108),
+							OutstandingExposure: decimal.NewFromInt(500),
+						},
+					},
+					Totals: []entity.PortfolioTotal{
+						{
+							Currency:                 money.IDR,
+							TotalInvested:            decimal.NewFromInt(600),
+							TotalExpectedReturn:      decimal.NewFromInt(48),
+							TotalPayoutsReceived:     decimal.NewFromInt(108),
+							TotalOutstandingExposure: decimal.NewFromInt(500),
+						},
+					},
+				}, nil)
+			},
+			expectStatus: http.StatusOK,
+			expectResponse: handler.Response{
+				Data: map[string]interface{}{
+					"loans": []interface{}{
+						map[string]interface{}{
+							"loan_id":              float64(1),
+							"status":               string(constants.StatusRepaying),
+							"currency":             string(money.IDR),
+							"roi":                  float64(8),
+							"amount_invested":      float64(600),
+							"expected_return":      float64(48),
+							"principal_received":   float64(100),
+							"return_received":      float64(8),
+							"payouts_received":     float64(108),
+							"outstanding_exposure": float64(500),
+						},
+					},
+					"totals": []interface{}{
+						map[string]interface{}{
+							"currency":                   string(money.IDR),
+							"total_invested":             float64(600),
+							"total_expected_return":      float64(48),
+							"total_payouts_received":     float64(108),
+							"total_outstanding_exposure": float64(500),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Wrong role",
+			mockFunc: func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleValidator, nil)
+			},
+			expectStatus: http.StatusForbidden,
+			expectResponse: handler.Response{
+				Error: errs.ErrUnauthorizedAction,
+			},
+		},
+		{
+			name: "GetPortfolio error",
+			mockFunc: func(mockInvestorUsecase *mocks.InvestorUsecaseInterface, mockUserUsecase *mocks.UserUsecaseInterface) {
+				mockUserUsecase.On("GetUserRole", uint(1)).Return(constants.RoleInvestor, nil)
+				mockInvestorUsecase.On("GetPortfolio", uint(1)).Return(nil, fmt.Errorf("error fetching portfolio"))
+			},
+			expectStatus: http.StatusInternalServerError,
+			expectResponse: handler.Response{
+				Error: "error fetching portfolio",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			mockInvestorUsecase := mocks.NewInvestorUsecaseInterface(t)
+			mockUserUsecase := mocks.NewUserUsecaseInterface(t)
+			auth.StartAuthorizer("test-secret")
+
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockInvestorUsecase, mockUserUsecase)
+			}
+
+			router := gin.Default()
+			handler.RegisterInvestorHandler(router.Group("/api"), mockInvestorUsecase, mockUserUsecase)
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/investors/me/portfolio", nil)
+			token, _ := auth.GenerateToken("testuser", 1)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectStatus, resp.Code)
+			var response handler.Response
+			err := json.Unmarshal(resp.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			if tt.expectStatus == http.StatusOK {
+				assert.Equal(t, tt.expectResponse.Data, response.Data)
+			} else {
+				assert.Equal(t, tt.expectResponse.Error, response.Error)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"loan-service/utils/constants"
 	errs "loan-service/utils/errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,12 +19,20 @@ func RegisterLoanHandler(r *gin.RouterGroup, loanUsecase LoanUsecaseInterface, u
 	h := &LoanHandler{loanUsecase: loanUsecase, userUsecase: userUsecase}
 	g := r.Group("/loans", authMiddleware())
 
+	g.GET("", h.listLoans)
 	g.POST("/create", h.createLoan)
+	g.POST("/resubmit", h.resubmitLoan)
 	g.GET("/:id", h.getLoan)
+	g.GET("/:id/schedule", h.getSchedule)
+	g.GET("/:id/history", h.getHistory)
 	g.POST("/reject", h.rejectLoan)
 	g.POST("/approve", h.approveLoan)
 	g.POST("/invest", h.addInvestment)
+	g.POST("/invest/cancel", h.cancelInvestment)
 	g.POST("/disburse", h.disburseLoan)
+	g.POST("/:id/repayments", h.addRepayment)
+	g.POST("/default", h.defaultLoan)
+	g.POST("/cancel", h.cancelLoan)
 }
 
 func (h *LoanHandler) getLoan(c *gin.Context) {
@@ -36,10 +45,92 @@ func (h *LoanHandler) getLoan(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": loan})
 }
 
+func (h *LoanHandler) listLoans(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	role, err := h.userUsecase.GetUserRole(userID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	var input entity.RequestListLoans
+	if err := c.ShouldBindQuery(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// borrowers can only browse their own loans and investors the loans open for investment or they invested in,
+	// the status and borrower filters only narrow down that scope
+	switch role {
+	case constants.RoleBorrower:
+		input.BorrowerID = userID
+	case constants.RoleInvestor:
+		input.InvestorID = userID
+	case constants.RoleAdmin, constants.RoleValidator, constants.RoleSupervisor, constants.RoleDisburser:
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	loans, nextCursor, err := h.loanUsecase.ListLoans(input)
+	if err != nil {
+		switch err.Error() {
+		case errs.ErrInvalidLoanFilter, errs.ErrInvalidCursor:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: loans, NextCursor: nextCursor})
+}
+
+func (h *LoanHandler) getSchedule(c *gin.Context) {
+	id := c.Param("id")
+	installments, err := h.loanUsecase.GetSchedule(id)
+	if err != nil {
+		switch err.Error() {
+		case errs.ErrLoanNotFound, errs.ErrScheduleNotAvailable:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": installments})
+}
+
+// getHistory returns the audit trail of a loan, who may read it is decided by the usecase
+func (h *LoanHandler) getHistory(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	role, err := h.userUsecase.GetUserRole(userID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	id := c.Param("id")
+	events, err := h.loanUsecase.GetHistory(id, entity.Actor{ID: userID, Role: role})
+	if err != nil {
+		switch err.Error() {
+		case errs.ErrLoanNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errs.ErrUnauthorizedAction:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": events})
+}
+
 func (h *LoanHandler) createLoan(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
 
-	if !h.verifyUserRole(userID, constants.RoleBorrower) {
+	actor, ok := authorizeUser(h.userUsecase, userID, constants.RoleBorrower)
+	if !ok {
 		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
 		return
 	}
@@ -50,14 +141,67 @@ func (h *LoanHandler) createLoan(c *gin.Context) {
 		return
 	}
 
-	if input.Principal <= 0 || input.ROI <= 0 || input.Rate <= 0 {
+	if !input.Principal.IsPositive() || !input.ROI.IsPositive() || !input.Rate.IsPositive() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan parameters"})
 		return
 	}
 
-	loan, err := h.loanUsecase.CreateLoan(input, userID)
+	loan, err := h.loanUsecase.CreateLoan(c, input, actor)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if isLimitExceeded(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		switch err.Error() {
+		case errs.ErrUnsupportedCurrency, errs.ErrInvalidMinorUnits:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errs.ErrConcurrentUpdate:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": loan})
+}
+
+func (h *LoanHandler) resubmitLoan(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	actor, ok := authorizeUser(h.userUsecase, userID, constants.RoleBorrower)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	var input entity.RequestResubmitLoan
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !input.Principal.IsPositive() || !input.ROI.IsPositive() || !input.Rate.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan parameters"})
+		return
+	}
+
+	loan, err := h.loanUsecase.ResubmitLoan(c, input, actor)
+	if err != nil {
+		if isLimitExceeded(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		switch err.Error() {
+		case errs.ErrUnauthorizedAction:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errs.ErrLoanNotResubmittable, errs.ErrLoanAlreadyResubmitted, errs.ErrAppealLimitReached,
+			errs.ErrUnsupportedCurrency, errs.ErrInvalidMinorUnits:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errs.ErrConcurrentUpdate:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"data": loan})
@@ -65,7 +209,8 @@ func (h *LoanHandler) createLoan(c *gin.Context) {
 
 func (h *LoanHandler) rejectLoan(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
-	if !h.verifyUserRole(userID, constants.RoleValidator) {
+	actor, ok := authorizeUser(h.userUsecase, userID, constants.RoleValidator)
+	if !ok {
 		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
 		return
 	}
@@ -76,7 +221,7 @@ func (h *LoanHandler) rejectLoan(c *gin.Context) {
 		return
 	}
 
-	rejection, err := h.loanUsecase.RejectLoan(input, userID)
+	rejection, err := h.loanUsecase.RejectLoan(c, input, actor)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -87,7 +232,8 @@ func (h *LoanHandler) rejectLoan(c *gin.Context) {
 
 func (h *LoanHandler) approveLoan(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
-	if !h.verifyUserRole(userID, constants.RoleValidator) {
+	actor, ok := authorizeUser(h.userUsecase, userID, constants.RoleValidator, constants.RoleSupervisor)
+	if !ok {
 		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
 		return
 	}
@@ -98,18 +244,31 @@ func (h *LoanHandler) approveLoan(c *gin.Context) {
 		return
 	}
 
-	approval, err := h.loanUsecase.ApproveLoan(input, userID)
+	approval, err := h.loanUsecase.ApproveLoan(c, input, actor)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch err.Error() {
+		case errs.ErrInvalidTicketSize, errs.ErrInvalidRiskGrade, errs.ErrRiskGradeJustificationRequired, errs.ErrAlreadySignedOff,
+			errs.ErrRiskGradeMismatch, errs.ErrTicketSizeMismatch, errs.ErrDocumentNotAttached:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errs.ErrUnauthorizedAction:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
+	if approval.Pending {
+		c.JSON(http.StatusAccepted, gin.H{"data": approval})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"data": approval})
 }
 
 func (h *LoanHandler) addInvestment(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
-	if !h.verifyUserRole(userID, constants.RoleInvestor) {
+	actor, ok := authorizeUser(h.userUsecase, userID, constants.RoleInvestor)
+	if !ok {
 		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
 		return
 	}
@@ -120,14 +279,25 @@ func (h *LoanHandler) addInvestment(c *gin.Context) {
 		return
 	}
 
-	if input.Amount <= 0 {
+	if !input.Amount.IsPositive() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: LoanID and Amount are required"})
 		return
 	}
 
-	investment, err := h.loanUsecase.AddInvestment(c, input, userID)
+	investment, err := h.loanUsecase.AddInvestment(c, input, actor)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if isLimitExceeded(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		switch err.Error() {
+		case errs.ErrCurrencyMismatch, errs.ErrInvalidMinorUnits, errs.ErrInvestmentBelowMinTicket, errs.ErrInvestmentAboveMaxTicket:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errs.ErrConcurrentUpdate:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -136,7 +306,8 @@ func (h *LoanHandler) addInvestment(c *gin.Context) {
 
 func (h *LoanHandler) disburseLoan(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
-	if !h.verifyUserRole(userID, constants.RoleDisburser) {
+	actor, ok := authorizeUser(h.userUsecase, userID, constants.RoleDisburser)
+	if !ok {
 		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
 		return
 	}
@@ -146,10 +317,138 @@ func (h *LoanHandler) disburseLoan(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	disbursement, err := h.loanUsecase.DisburseLoan(input, userID)
+	disbursement, err := h.loanUsecase.DisburseLoan(c, input, actor)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch err.Error() {
+		case errs.ErrDocumentNotAttached:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": disbursement})
 }
+
+func (h *LoanHandler) addRepayment(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	actor, ok := authorizeUser(h.userUsecase, userID, constants.RoleBorrower)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	loanID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errs.ErrLoanNotFound})
+		return
+	}
+
+	var input entity.RequestAddRepayment
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !input.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: Amount must be positive"})
+		return
+	}
+	input.LoanID = uint(loanID)
+
+	repayment, err := h.loanUsecase.AddRepayment(c, input, actor)
+	if err != nil {
+		switch err.Error() {
+		case errs.ErrInvalidMinorUnits:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": repayment})
+}
+
+func (h *LoanHandler) defaultLoan(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	actor, ok := authorizeUser(h.userUsecase, userID, constants.RoleAdmin)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	var input entity.RequestDefaultLoan
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loan, err := h.loanUsecase.DefaultLoan(c, input, actor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": loan})
+}
+
+func (h *LoanHandler) cancelLoan(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	actor, ok := authorizeUser(h.userUsecase, userID, constants.RoleBorrower)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	var input entity.RequestCancelLoan
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loan, err := h.loanUsecase.CancelLoan(c, input, actor)
+	if err != nil {
+		switch err.Error() {
+		case errs.ErrUnauthorizedAction:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errs.ErrLoanNotCancellable:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": loan})
+}
+
+func (h *LoanHandler) cancelInvestment(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	actor, ok := authorizeUser(h.userUsecase, userID, constants.RoleInvestor)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": errs.ErrUnauthorizedAction})
+		return
+	}
+
+	var input entity.RequestCancelInvestment
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	investment, err := h.loanUsecase.CancelInvestment(c, input, actor)
+	if err != nil {
+		switch err.Error() {
+		case errs.ErrUnauthorizedAction:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errs.ErrInvestmentNotCancellable, errs.ErrInvestmentGraceExpired:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": investment})
+}
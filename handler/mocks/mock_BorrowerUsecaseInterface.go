@@ -0,0 +1,58 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	entity "loan-service/entity"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// BorrowerUsecaseInterface is an autogenerated mock type for the BorrowerUsecaseInterface type
+type BorrowerUsecaseInterface struct {
+	mock.Mock
+}
+
+// GetLoans provides a mock function with given fields: borrowerID
+func (_m *BorrowerUsecaseInterface) GetLoans(borrowerID uint) ([]entity.BorrowerLoan, error) {
+	ret := _m.Called(borrowerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLoans")
+	}
+
+	var r0 []entity.BorrowerLoan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uint) ([]entity.BorrowerLoan, error)); ok {
+		return rf(borrowerID)
+	}
+	if rf, ok := ret.Get(0).(func(uint) []entity.BorrowerLoan); ok {
+		r0 = rf(borrowerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.BorrowerLoan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(borrowerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewBorrowerUsecaseInterface creates a new instance of BorrowerUsecaseInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewBorrowerUsecaseInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *BorrowerUsecaseInterface {
+	mock := &BorrowerUsecaseInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,100 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	entity "loan-service/entity"
+
+	io "io"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// DocumentUsecaseInterface is an autogenerated mock type for the DocumentUsecaseInterface type
+type DocumentUsecaseInterface struct {
+	mock.Mock
+}
+
+// OpenDocument provides a mock function with given fields: ctx, documentID, actor
+func (_m *DocumentUsecaseInterface) OpenDocument(ctx context.Context, documentID uint, actor entity.Actor) (*entity.Document, io.ReadCloser, error) {
+	ret := _m.Called(ctx, documentID, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OpenDocument")
+	}
+
+	var r0 *entity.Document
+	var r1 io.ReadCloser
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, entity.Actor) (*entity.Document, io.ReadCloser, error)); ok {
+		return rf(ctx, documentID, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint, entity.Actor) *entity.Document); ok {
+		r0 = rf(ctx, documentID, actor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Document)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint, entity.Actor) io.ReadCloser); ok {
+		r1 = rf(ctx, documentID, actor)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(io.ReadCloser)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, uint, entity.Actor) error); ok {
+		r2 = rf(ctx, documentID, actor)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// UploadDocument provides a mock function with given fields: ctx, uploadRequest, body, uploader
+func (_m *DocumentUsecaseInterface) UploadDocument(ctx context.Context, uploadRequest entity.RequestUploadDocument, body []byte, uploader entity.Actor) (*entity.Document, error) {
+	ret := _m.Called(ctx, uploadRequest, body, uploader)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UploadDocument")
+	}
+
+	var r0 *entity.Document
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestUploadDocument, []byte, entity.Actor) (*entity.Document, error)); ok {
+		return rf(ctx, uploadRequest, body, uploader)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestUploadDocument, []byte, entity.Actor) *entity.Document); ok {
+		r0 = rf(ctx, uploadRequest, body, uploader)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Document)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.RequestUploadDocument, []byte, entity.Actor) error); ok {
+		r1 = rf(ctx, uploadRequest, body, uploader)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewDocumentUsecaseInterface creates a new instance of DocumentUsecaseInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewDocumentUsecaseInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *DocumentUsecaseInterface {
+	mock := &DocumentUsecaseInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,125 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	entity "loan-service/entity"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// InvestorUsecaseInterface is an autogenerated mock type for the InvestorUsecaseInterface type
+type InvestorUsecaseInterface struct {
+	mock.Mock
+}
+
+// GetMarketplace provides a mock function with given fields: marketplaceRequest
+func (_m *InvestorUsecaseInterface) GetMarketplace(marketplaceRequest entity.RequestMarketplace) ([]entity.MarketplaceLoan, string, error) {
+	ret := _m.Called(marketplaceRequest)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMarketplace")
+	}
+
+	var r0 []entity.MarketplaceLoan
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(entity.RequestMarketplace) ([]entity.MarketplaceLoan, string, error)); ok {
+		return rf(marketplaceRequest)
+	}
+	if rf, ok := ret.Get(0).(func(entity.RequestMarketplace) []entity.MarketplaceLoan); ok {
+		r0 = rf(marketplaceRequest)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.MarketplaceLoan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(entity.RequestMarketplace) string); ok {
+		r1 = rf(marketplaceRequest)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(entity.RequestMarketplace) error); ok {
+		r2 = rf(marketplaceRequest)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetPayouts provides a mock function with given fields: investorID, loanID
+func (_m *InvestorUsecaseInterface) GetPayouts(investorID uint, loanID uint) ([]entity.Payout, error) {
+	ret := _m.Called(investorID, loanID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPayouts")
+	}
+
+	var r0 []entity.Payout
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uint, uint) ([]entity.Payout, error)); ok {
+		return rf(investorID, loanID)
+	}
+	if rf, ok := ret.Get(0).(func(uint, uint) []entity.Payout); ok {
+		r0 = rf(investorID, loanID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Payout)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uint, uint) error); ok {
+		r1 = rf(investorID, loanID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPortfolio provides a mock function with given fields: investorID
+func (_m *InvestorUsecaseInterface) GetPortfolio(investorID uint) (*entity.Portfolio, error) {
+	ret := _m.Called(investorID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPortfolio")
+	}
+
+	var r0 *entity.Portfolio
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uint) (*entity.Portfolio, error)); ok {
+		return rf(investorID)
+	}
+	if rf, ok := ret.Get(0).(func(uint) *entity.Portfolio); ok {
+		r0 = rf(investorID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Portfolio)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(investorID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewInvestorUsecaseInterface creates a new instance of InvestorUsecaseInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewInvestorUsecaseInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *InvestorUsecaseInterface {
+	mock := &InvestorUsecaseInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -14,9 +14,9 @@ type LoanUsecaseInterface struct {
 	mock.Mock
 }
 
-// AddInvestment provides a mock function with given fields: ctx, investmentRequest, investorID
-func (_m *LoanUsecaseInterface) AddInvestment(ctx context.Context, investmentRequest entity.RequestAddInvestment, investorID uint) (*entity.Investment, error) {
-	ret := _m.Called(ctx, investmentRequest, investorID)
+// AddInvestment provides a mock function with given fields: ctx, investmentRequest, investor
+func (_m *LoanUsecaseInterface) AddInvestment(ctx context.Context, investmentRequest entity.RequestAddInvestment, investor entity.Actor) (*entity.Investment, error) {
+	ret := _m.Called(ctx, investmentRequest, investor)
 
 	if len(ret) == 0 {
 		panic("no return value specified for AddInvestment")
@@ -24,19 +24,19 @@ func (_m *LoanUsecaseInterface) AddInvestment(ctx context.Context, investmentReq
 
 	var r0 *entity.Investment
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestAddInvestment, uint) (*entity.Investment, error)); ok {
-		return rf(ctx, investmentRequest, investorID)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestAddInvestment, entity.Actor) (*entity.Investment, error)); ok {
+		return rf(ctx, investmentRequest, investor)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestAddInvestment, uint) *entity.Investment); ok {
-		r0 = rf(ctx, investmentRequest, investorID)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestAddInvestment, entity.Actor) *entity.Investment); ok {
+		r0 = rf(ctx, investmentRequest, investor)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entity.Investment)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, entity.RequestAddInvestment, uint) error); ok {
-		r1 = rf(ctx, investmentRequest, investorID)
+	if rf, ok := ret.Get(1).(func(context.Context, entity.RequestAddInvestment, entity.Actor) error); ok {
+		r1 = rf(ctx, investmentRequest, investor)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -44,9 +44,39 @@ func (_m *LoanUsecaseInterface) AddInvestment(ctx context.Context, investmentReq
 	return r0, r1
 }
 
-// ApproveLoan provides a mock function with given fields: approvalRequest, validatorID
-func (_m *LoanUsecaseInterface) ApproveLoan(approvalRequest entity.RequestApproveLoan, validatorID uint) (*entity.LoanApproval, error) {
-	ret := _m.Called(approvalRequest, validatorID)
+// AddRepayment provides a mock function with given fields: ctx, repaymentRequest, payer
+func (_m *LoanUsecaseInterface) AddRepayment(ctx context.Context, repaymentRequest entity.RequestAddRepayment, payer entity.Actor) (*entity.Repayment, error) {
+	ret := _m.Called(ctx, repaymentRequest, payer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddRepayment")
+	}
+
+	var r0 *entity.Repayment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestAddRepayment, entity.Actor) (*entity.Repayment, error)); ok {
+		return rf(ctx, repaymentRequest, payer)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestAddRepayment, entity.Actor) *entity.Repayment); ok {
+		r0 = rf(ctx, repaymentRequest, payer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Repayment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.RequestAddRepayment, entity.Actor) error); ok {
+		r1 = rf(ctx, repaymentRequest, payer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ApproveLoan provides a mock function with given fields: ctx, approvalRequest, validator
+func (_m *LoanUsecaseInterface) ApproveLoan(ctx context.Context, approvalRequest entity.RequestApproveLoan, validator entity.Actor) (*entity.LoanApproval, error) {
+	ret := _m.Called(ctx, approvalRequest, validator)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ApproveLoan")
@@ -54,19 +84,49 @@ func (_m *LoanUsecaseInterface) ApproveLoan(approvalRequest entity.RequestApprov
 
 	var r0 *entity.LoanApproval
 	var r1 error
-	if rf, ok := ret.Get(0).(func(entity.RequestApproveLoan, uint) (*entity.LoanApproval, error)); ok {
-		return rf(approvalRequest, validatorID)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestApproveLoan, entity.Actor) (*entity.LoanApproval, error)); ok {
+		return rf(ctx, approvalRequest, validator)
 	}
-	if rf, ok := ret.Get(0).(func(entity.RequestApproveLoan, uint) *entity.LoanApproval); ok {
-		r0 = rf(approvalRequest, validatorID)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestApproveLoan, entity.Actor) *entity.LoanApproval); ok {
+		r0 = rf(ctx, approvalRequest, validator)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entity.LoanApproval)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(entity.RequestApproveLoan, uint) error); ok {
-		r1 = rf(approvalRequest, validatorID)
+	if rf, ok := ret.Get(1).(func(context.Context, entity.RequestApproveLoan, entity.Actor) error); ok {
+		r1 = rf(ctx, approvalRequest, validator)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelInvestment provides a mock function with given fields: ctx, cancelRequest, investor
+func (_m *LoanUsecaseInterface) CancelInvestment(ctx context.Context, cancelRequest entity.RequestCancelInvestment, investor entity.Actor) (*entity.Investment, error) {
+	ret := _m.Called(ctx, cancelRequest, investor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelInvestment")
+	}
+
+	var r0 *entity.Investment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestCancelInvestment, entity.Actor) (*entity.Investment, error)); ok {
+		return rf(ctx, cancelRequest, investor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestCancelInvestment, entity.Actor) *entity.Investment); ok {
+		r0 = rf(ctx, cancelRequest, investor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Investment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.RequestCancelInvestment, entity.Actor) error); ok {
+		r1 = rf(ctx, cancelRequest, investor)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -74,9 +134,39 @@ func (_m *LoanUsecaseInterface) ApproveLoan(approvalRequest entity.RequestApprov
 	return r0, r1
 }
 
-// CreateLoan provides a mock function with given fields: loanRequest, borrowerID
-func (_m *LoanUsecaseInterface) CreateLoan(loanRequest entity.RequestProposeLoan, borrowerID uint) (*entity.Loan, error) {
-	ret := _m.Called(loanRequest, borrowerID)
+// CancelLoan provides a mock function with given fields: ctx, cancelRequest, borrower
+func (_m *LoanUsecaseInterface) CancelLoan(ctx context.Context, cancelRequest entity.RequestCancelLoan, borrower entity.Actor) (*entity.Loan, error) {
+	ret := _m.Called(ctx, cancelRequest, borrower)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelLoan")
+	}
+
+	var r0 *entity.Loan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestCancelLoan, entity.Actor) (*entity.Loan, error)); ok {
+		return rf(ctx, cancelRequest, borrower)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestCancelLoan, entity.Actor) *entity.Loan); ok {
+		r0 = rf(ctx, cancelRequest, borrower)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Loan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.RequestCancelLoan, entity.Actor) error); ok {
+		r1 = rf(ctx, cancelRequest, borrower)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateLoan provides a mock function with given fields: ctx, loanRequest, borrower
+func (_m *LoanUsecaseInterface) CreateLoan(ctx context.Context, loanRequest entity.RequestProposeLoan, borrower entity.Actor) (*entity.Loan, error) {
+	ret := _m.Called(ctx, loanRequest, borrower)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateLoan")
@@ -84,19 +174,19 @@ func (_m *LoanUsecaseInterface) CreateLoan(loanRequest entity.RequestProposeLoan
 
 	var r0 *entity.Loan
 	var r1 error
-	if rf, ok := ret.Get(0).(func(entity.RequestProposeLoan, uint) (*entity.Loan, error)); ok {
-		return rf(loanRequest, borrowerID)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestProposeLoan, entity.Actor) (*entity.Loan, error)); ok {
+		return rf(ctx, loanRequest, borrower)
 	}
-	if rf, ok := ret.Get(0).(func(entity.RequestProposeLoan, uint) *entity.Loan); ok {
-		r0 = rf(loanRequest, borrowerID)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestProposeLoan, entity.Actor) *entity.Loan); ok {
+		r0 = rf(ctx, loanRequest, borrower)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entity.Loan)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(entity.RequestProposeLoan, uint) error); ok {
-		r1 = rf(loanRequest, borrowerID)
+	if rf, ok := ret.Get(1).(func(context.Context, entity.RequestProposeLoan, entity.Actor) error); ok {
+		r1 = rf(ctx, loanRequest, borrower)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -104,9 +194,39 @@ func (_m *LoanUsecaseInterface) CreateLoan(loanRequest entity.RequestProposeLoan
 	return r0, r1
 }
 
-// DisburseLoan provides a mock function with given fields: disbursementRequest, disburserID
-func (_m *LoanUsecaseInterface) DisburseLoan(disbursementRequest entity.RequestDisburseLoan, disburserID uint) (*entity.LoanDisbursement, error) {
-	ret := _m.Called(disbursementRequest, disburserID)
+// DefaultLoan provides a mock function with given fields: ctx, defaultRequest, staff
+func (_m *LoanUsecaseInterface) DefaultLoan(ctx context.Context, defaultRequest entity.RequestDefaultLoan, staff entity.Actor) (*entity.Loan, error) {
+	ret := _m.Called(ctx, defaultRequest, staff)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DefaultLoan")
+	}
+
+	var r0 *entity.Loan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestDefaultLoan, entity.Actor) (*entity.Loan, error)); ok {
+		return rf(ctx, defaultRequest, staff)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestDefaultLoan, entity.Actor) *entity.Loan); ok {
+		r0 = rf(ctx, defaultRequest, staff)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Loan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.RequestDefaultLoan, entity.Actor) error); ok {
+		r1 = rf(ctx, defaultRequest, staff)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DisburseLoan provides a mock function with given fields: ctx, disbursementRequest, disburser
+func (_m *LoanUsecaseInterface) DisburseLoan(ctx context.Context, disbursementRequest entity.RequestDisburseLoan, disburser entity.Actor) (*entity.LoanDisbursement, error) {
+	ret := _m.Called(ctx, disbursementRequest, disburser)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DisburseLoan")
@@ -114,19 +234,49 @@ func (_m *LoanUsecaseInterface) DisburseLoan(disbursementRequest entity.RequestD
 
 	var r0 *entity.LoanDisbursement
 	var r1 error
-	if rf, ok := ret.Get(0).(func(entity.RequestDisburseLoan, uint) (*entity.LoanDisbursement, error)); ok {
-		return rf(disbursementRequest, disburserID)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestDisburseLoan, entity.Actor) (*entity.LoanDisbursement, error)); ok {
+		return rf(ctx, disbursementRequest, disburser)
 	}
-	if rf, ok := ret.Get(0).(func(entity.RequestDisburseLoan, uint) *entity.LoanDisbursement); ok {
-		r0 = rf(disbursementRequest, disburserID)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestDisburseLoan, entity.Actor) *entity.LoanDisbursement); ok {
+		r0 = rf(ctx, disbursementRequest, disburser)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entity.LoanDisbursement)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(entity.RequestDisburseLoan, uint) error); ok {
-		r1 = rf(disbursementRequest, disburserID)
+	if rf, ok := ret.Get(1).(func(context.Context, entity.RequestDisburseLoan, entity.Actor) error); ok {
+		r1 = rf(ctx, disbursementRequest, disburser)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHistory provides a mock function with given fields: loanID, actor
+func (_m *LoanUsecaseInterface) GetHistory(loanID string, actor entity.Actor) ([]entity.LoanEvent, error) {
+	ret := _m.Called(loanID, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetHistory")
+	}
+
+	var r0 []entity.LoanEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, entity.Actor) ([]entity.LoanEvent, error)); ok {
+		return rf(loanID, actor)
+	}
+	if rf, ok := ret.Get(0).(func(string, entity.Actor) []entity.LoanEvent); ok {
+		r0 = rf(loanID, actor)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.LoanEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, entity.Actor) error); ok {
+		r1 = rf(loanID, actor)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -164,9 +314,76 @@ func (_m *LoanUsecaseInterface) GetLoan(loanID string) (*entity.Loan, error) {
 	return r0, r1
 }
 
-// RejectLoan provides a mock function with given fields: rejectionRequest, validatorID
-func (_m *LoanUsecaseInterface) RejectLoan(rejectionRequest entity.RequestRejectLoan, validatorID uint) (*entity.LoanApproval, error) {
-	ret := _m.Called(rejectionRequest, validatorID)
+// GetSchedule provides a mock function with given fields: loanID
+func (_m *LoanUsecaseInterface) GetSchedule(loanID string) ([]entity.Installment, error) {
+	ret := _m.Called(loanID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSchedule")
+	}
+
+	var r0 []entity.Installment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]entity.Installment, error)); ok {
+		return rf(loanID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []entity.Installment); ok {
+		r0 = rf(loanID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Installment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(loanID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListLoans provides a mock function with given fields: listRequest
+func (_m *LoanUsecaseInterface) ListLoans(listRequest entity.RequestListLoans) ([]entity.Loan, string, error) {
+	ret := _m.Called(listRequest)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListLoans")
+	}
+
+	var r0 []entity.Loan
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(entity.RequestListLoans) ([]entity.Loan, string, error)); ok {
+		return rf(listRequest)
+	}
+	if rf, ok := ret.Get(0).(func(entity.RequestListLoans) []entity.Loan); ok {
+		r0 = rf(listRequest)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Loan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(entity.RequestListLoans) string); ok {
+		r1 = rf(listRequest)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(entity.RequestListLoans) error); ok {
+		r2 = rf(listRequest)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RejectLoan provides a mock function with given fields: ctx, rejectionRequest, validator
+func (_m *LoanUsecaseInterface) RejectLoan(ctx context.Context, rejectionRequest entity.RequestRejectLoan, validator entity.Actor) (*entity.LoanApproval, error) {
+	ret := _m.Called(ctx, rejectionRequest, validator)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RejectLoan")
@@ -174,19 +391,49 @@ func (_m *LoanUsecaseInterface) RejectLoan(rejectionRequest entity.RequestReject
 
 	var r0 *entity.LoanApproval
 	var r1 error
-	if rf, ok := ret.Get(0).(func(entity.RequestRejectLoan, uint) (*entity.LoanApproval, error)); ok {
-		return rf(rejectionRequest, validatorID)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestRejectLoan, entity.Actor) (*entity.LoanApproval, error)); ok {
+		return rf(ctx, rejectionRequest, validator)
 	}
-	if rf, ok := ret.Get(0).(func(entity.RequestRejectLoan, uint) *entity.LoanApproval); ok {
-		r0 = rf(rejectionRequest, validatorID)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestRejectLoan, entity.Actor) *entity.LoanApproval); ok {
+		r0 = rf(ctx, rejectionRequest, validator)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entity.LoanApproval)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(entity.RequestRejectLoan, uint) error); ok {
-		r1 = rf(rejectionRequest, validatorID)
+	if rf, ok := ret.Get(1).(func(context.Context, entity.RequestRejectLoan, entity.Actor) error); ok {
+		r1 = rf(ctx, rejectionRequest, validator)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResubmitLoan provides a mock function with given fields: ctx, resubmitRequest, borrower
+func (_m *LoanUsecaseInterface) ResubmitLoan(ctx context.Context, resubmitRequest entity.RequestResubmitLoan, borrower entity.Actor) (*entity.Loan, error) {
+	ret := _m.Called(ctx, resubmitRequest, borrower)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResubmitLoan")
+	}
+
+	var r0 *entity.Loan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestResubmitLoan, entity.Actor) (*entity.Loan, error)); ok {
+		return rf(ctx, resubmitRequest, borrower)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.RequestResubmitLoan, entity.Actor) *entity.Loan); ok {
+		r0 = rf(ctx, resubmitRequest, borrower)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Loan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.RequestResubmitLoan, entity.Actor) error); ok {
+		r1 = rf(ctx, resubmitRequest, borrower)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -2,20 +2,44 @@ package handler
 
 import (
 	"context"
+	"io"
 	"loan-service/entity"
 	"loan-service/utils/constants"
 )
 
 type LoanUsecaseInterface interface {
-	CreateLoan(loanRequest entity.RequestProposeLoan, borrowerID uint) (*entity.Loan, error)
-	RejectLoan(rejectionRequest entity.RequestRejectLoan, validatorID uint) (*entity.LoanApproval, error)
-	ApproveLoan(approvalRequest entity.RequestApproveLoan, validatorID uint) (*entity.LoanApproval, error)
-	AddInvestment(ctx context.Context, investmentRequest entity.RequestAddInvestment, investorID uint) (*entity.Investment, error)
-	DisburseLoan(disbursementRequest entity.RequestDisburseLoan, disburserID uint) (*entity.LoanDisbursement, error)
+	CreateLoan(ctx context.Context, loanRequest entity.RequestProposeLoan, borrower entity.Actor) (*entity.Loan, error)
+	ResubmitLoan(ctx context.Context, resubmitRequest entity.RequestResubmitLoan, borrower entity.Actor) (*entity.Loan, error)
+	RejectLoan(ctx context.Context, rejectionRequest entity.RequestRejectLoan, validator entity.Actor) (*entity.LoanApproval, error)
+	ApproveLoan(ctx context.Context, approvalRequest entity.RequestApproveLoan, validator entity.Actor) (*entity.LoanApproval, error)
+	AddInvestment(ctx context.Context, investmentRequest entity.RequestAddInvestment, investor entity.Actor) (*entity.Investment, error)
+	DisburseLoan(ctx context.Context, disbursementRequest entity.RequestDisburseLoan, disburser entity.Actor) (*entity.LoanDisbursement, error)
 	GetLoan(loanID string) (*entity.Loan, error)
+	ListLoans(listRequest entity.RequestListLoans) ([]entity.Loan, string, error)
+	GetSchedule(loanID string) ([]entity.Installment, error)
+	GetHistory(loanID string, actor entity.Actor) ([]entity.LoanEvent, error)
+	AddRepayment(ctx context.Context, repaymentRequest entity.RequestAddRepayment, payer entity.Actor) (*entity.Repayment, error)
+	DefaultLoan(ctx context.Context, defaultRequest entity.RequestDefaultLoan, staff entity.Actor) (*entity.Loan, error)
+	CancelLoan(ctx context.Context, cancelRequest entity.RequestCancelLoan, borrower entity.Actor) (*entity.Loan, error)
+	CancelInvestment(ctx context.Context, cancelRequest entity.RequestCancelInvestment, investor entity.Actor) (*entity.Investment, error)
+}
+
+type InvestorUsecaseInterface interface {
+	GetPayouts(investorID uint, loanID uint) ([]entity.Payout, error)
+	GetMarketplace(marketplaceRequest entity.RequestMarketplace) ([]entity.MarketplaceLoan, string, error)
+	GetPortfolio(investorID uint) (*entity.Portfolio, error)
+}
+
+type BorrowerUsecaseInterface interface {
+	GetLoans(borrowerID uint) ([]entity.BorrowerLoan, error)
 }
 
 type UserUsecaseInterface interface {
 	GetUserByUsername(username string) (*entity.User, error)
 	GetUserRole(userID uint) (constants.UserRole, error)
 }
+
+type DocumentUsecaseInterface interface {
+	UploadDocument(ctx context.Context, uploadRequest entity.RequestUploadDocument, body []byte, uploader entity.Actor) (*entity.Document, error)
+	OpenDocument(ctx context.Context, documentID uint, actor entity.Actor) (*entity.Document, io.ReadCloser, error)
+}
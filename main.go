@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"loan-service/entity"
 	"loan-service/handler"
 	"loan-service/usecase"
 	"loan-service/utils/auth"
 	"loan-service/utils/config"
+	"loan-service/utils/constants"
+	"loan-service/utils/mailer"
+	"loan-service/utils/storage"
+	"reflect"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
@@ -19,7 +24,7 @@ func main() {
 	config.LoadConfig()
 
 	Conf := config.Conf
-	if Conf == (config.Config{}) {
+	if reflect.DeepEqual(Conf, config.Config{}) {
 		panic("Configuration is not loaded properly")
 	}
 
@@ -30,7 +35,9 @@ func main() {
 		panic(err)
 	}
 
-	db.AutoMigrate(&entity.Loan{}, &entity.LoanApproval{}, &entity.Investment{}, &entity.LoanDisbursement{})
+	db.AutoMigrate(&entity.Loan{}, &entity.LoanApproval{}, &entity.Investment{}, &entity.LoanDisbursement{}, &entity.Installment{},
+		&entity.Repayment{}, &entity.RepaymentAllocation{}, &entity.Payout{},
+		&entity.Notification{}, &entity.LoanEvent{}, &entity.ApprovalSignoff{}, &entity.Document{})
 
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", Conf.RedisHost, Conf.RedisPort),
@@ -44,6 +51,7 @@ func main() {
 
 	auth.StartAuthorizer(Conf.AuthSecret)
 	g := gin.Default()
+	g.Use(handler.RequestIDMiddleware())
 	r := g.Group("/api")
 
 	r.GET("/health", func(c *gin.Context) {
@@ -51,10 +59,78 @@ func main() {
 	})
 
 	userUsecase := usecase.NewUserUsecase(db)
-	loanUsecase := usecase.NewLoanUsecase(db, rdb)
+	notificationUsecase := usecase.NewNotificationUsecase(db,
+		mailer.NewSMTPMailer(Conf.SMTPHost, Conf.SMTPPort, Conf.SMTPUser, Conf.SMTPPass, Conf.SMTPFrom))
+	minTicket := Conf.MinTicket
+	if len(minTicket) == 0 {
+		if err := minTicket.UnmarshalText([]byte(constants.DefaultMinTicket)); err != nil {
+			panic(err)
+		}
+	}
+	store, err := newDocumentStore(Conf)
+	if err != nil {
+		panic(err)
+	}
+	publicURL := Conf.PublicURL
+	if publicURL == "" {
+		publicURL = constants.DefaultPublicURL
+	}
+	documentUsecase := usecase.NewDocumentUsecase(db, store, publicURL)
+	loanUsecase := usecase.NewLoanUsecase(db, rdb, notificationUsecase, documentUsecase, usecase.LoanPolicy{
+		InvestmentGracePeriod:  Conf.InvestmentGracePeriod,
+		MinTicket:              minTicket,
+		MaxTicket:              Conf.MaxTicket,
+		MaxInvestorLoanShare:   Conf.MaxInvestorLoanShare,
+		MaxBorrowerExposure:    Conf.MaxBorrowerExposure,
+		MaxBorrowerActiveLoans: Conf.MaxBorrowerActiveLoans,
+		MaxBorrowerOutstanding: Conf.MaxBorrowerOutstanding,
+		DualApprovalThreshold:  Conf.DualApprovalThreshold,
+		MaxAppeals:             Conf.MaxAppeals,
+	})
+	investorUsecase := usecase.NewInvestorUsecase(db)
+	borrowerUsecase := usecase.NewBorrowerUsecase(db)
 
 	handler.RegisterLoanHandler(r, loanUsecase, userUsecase)
 	handler.RegisterUserHandler(r, userUsecase)
+	handler.RegisterInvestorHandler(r, investorUsecase, userUsecase)
+	handler.RegisterBorrowerHandler(r, borrowerUsecase, userUsecase)
+	maxUploadSize := Conf.MaxUploadSize
+	if maxUploadSize <= 0 {
+		maxUploadSize = constants.DefaultMaxUploadSize
+	}
+	handler.RegisterDocumentHandler(r, documentUsecase, userUsecase, maxUploadSize)
+
+	retryInterval := Conf.NotificationRetryInterval
+	if retryInterval <= 0 {
+		retryInterval = constants.DefaultNotificationRetryInterval
+	}
+	go notificationUsecase.RunDeliveryWorker(context.Background(), retryInterval)
+
+	fundingWindow := Conf.FundingWindow
+	if fundingWindow <= 0 {
+		fundingWindow = constants.DefaultFundingWindow
+	}
+	expiryInterval := Conf.LoanExpiryInterval
+	if expiryInterval <= 0 {
+		expiryInterval = constants.DefaultLoanExpiryInterval
+	}
+	go loanUsecase.RunExpiryWorker(context.Background(), expiryInterval, fundingWindow)
 
 	g.Run(":8080")
 }
+
+// newDocumentStore keeps documents on the local filesystem unless DOCUMENT_STORE selects an S3 compatible store
+func newDocumentStore(conf config.Config) (storage.Store, error) {
+	switch conf.DocumentStore {
+	case "", constants.DocumentStoreFilesystem:
+		dir := conf.DocumentDir
+		if dir == "" {
+			dir = constants.DefaultDocumentDir
+		}
+		return storage.NewFileStore(dir)
+	case constants.DocumentStoreS3:
+		return storage.NewS3Store(conf.S3Endpoint, conf.S3Region, conf.S3Bucket, conf.S3AccessKey, conf.S3SecretKey), nil
+	default:
+		return nil, fmt.Errorf("unknown DOCUMENT_STORE %q", conf.DocumentStore)
+	}
+}
@@ -1,4 +1,12 @@
+DROP TABLE IF EXISTS documents CASCADE;
+DROP TABLE IF EXISTS loan_events CASCADE;
+DROP TABLE IF EXISTS notifications CASCADE;
+DROP TABLE IF EXISTS payouts CASCADE;
+DROP TABLE IF EXISTS repayment_allocations CASCADE;
+DROP TABLE IF EXISTS repayments CASCADE;
+DROP TABLE IF EXISTS installments CASCADE;
 DROP TABLE IF EXISTS loan_disbursements CASCADE;
+DROP TABLE IF EXISTS approval_signoffs CASCADE;
 DROP TABLE IF EXISTS loan_approvals CASCADE;
 DROP TABLE IF EXISTS investments CASCADE;
 DROP TABLE IF EXISTS loans CASCADE;
@@ -9,44 +17,95 @@ CREATE TABLE users (
     id SERIAL PRIMARY KEY,
     username TEXT UNIQUE NOT NULL,
     role INT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
     created_at TIMESTAMP WITH TIME ZONE NOT NULL,
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL
 );
 
-INSERT INTO users (id, username, role, created_at, updated_at) VALUES
-('1', 'borrower1', 1, NOW(), NOW()),
-('2', 'validator', 2, NOW(), NOW()),
-('3', 'investor1', 3, NOW(), NOW()),
-('4', 'investor2', 3, NOW(), NOW()),
-('5', 'disburser', 4, NOW(), NOW());
+INSERT INTO users (id, username, role, email, created_at, updated_at) VALUES
+('1', 'borrower1', 1, 'borrower1@example.com', NOW(), NOW()),
+('2', 'validator', 2, 'validator@example.com', NOW(), NOW()),
+('3', 'investor1', 3, 'investor1@example.com', NOW(), NOW()),
+('4', 'investor2', 3, 'investor2@example.com', NOW(), NOW()),
+('5', 'disburser', 4, 'disburser@example.com', NOW(), NOW()),
+('6', 'admin', 0, 'admin@example.com', NOW(), NOW()),
+('7', 'supervisor', 5, 'supervisor@example.com', NOW(), NOW()),
+('8', 'validator2', 2, 'validator2@example.com', NOW(), NOW());
 
 CREATE TABLE loans (
     id SERIAL PRIMARY KEY,
     borrower_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     principal NUMERIC NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'IDR',
     rate NUMERIC NOT NULL,
     roi NUMERIC NOT NULL,
+    tenor INT NOT NULL DEFAULT 12,
+    frequency TEXT NOT NULL DEFAULT 'monthly',
     status TEXT NOT NULL,
     agreement_link TEXT,
+    agreement_version TEXT NOT NULL DEFAULT '',
+    min_ticket NUMERIC,
+    max_ticket NUMERIC,
+    resubmission_of INT REFERENCES loans(id),
     created_at TIMESTAMP WITH TIME ZONE NOT NULL,
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL
 );
 
+-- a rejected loan can only be resubmitted once
+CREATE UNIQUE INDEX idx_loans_resubmission_of ON loans(resubmission_of);
+
+CREATE TABLE documents (
+    id SERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    name TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    mime_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    owner_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    loan_id INT REFERENCES loans(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_documents_loan_id ON documents(loan_id);
+
 CREATE TABLE loan_approvals (
     id SERIAL PRIMARY KEY,
     loan_id INT NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
     validator_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     reject_reason TEXT,
     photo_url TEXT NOT NULL,
+    photo_document_id INT REFERENCES documents(id),
     approved_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    risk_score INT,
+    scored_risk_grade TEXT NOT NULL DEFAULT '',
+    risk_grade TEXT NOT NULL DEFAULT '',
+    risk_grade_justification TEXT,
     created_at TIMESTAMP WITH TIME ZONE NOT NULL,
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL
 );
 
+CREATE TABLE approval_signoffs (
+    id SERIAL PRIMARY KEY,
+    loan_id INT NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
+    approver_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    photo_document_id INT REFERENCES documents(id),
+    risk_grade TEXT NOT NULL DEFAULT '',
+    risk_grade_justification TEXT,
+    min_ticket NUMERIC,
+    max_ticket NUMERIC,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (loan_id, approver_id)
+);
+
 CREATE TABLE loan_disbursements (
     id SERIAL PRIMARY KEY,
     loan_id INT NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
     signed_agreement_url TEXT NOT NULL,
+    signed_agreement_document_id INT REFERENCES documents(id),
     disburser_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     disbursed_at TIMESTAMP WITH TIME ZONE NOT NULL,
     created_at TIMESTAMP WITH TIME ZONE NOT NULL,
@@ -58,6 +117,96 @@ CREATE TABLE investments (
     loan_id INT NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
     investor_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     amount NUMERIC NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'IDR',
+    status TEXT NOT NULL DEFAULT 'active',
+    agreement_link TEXT,
+    agreement_version TEXT NOT NULL DEFAULT '',
     created_at TIMESTAMP WITH TIME ZONE NOT NULL,
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL
 );
+
+CREATE TABLE installments (
+    id SERIAL PRIMARY KEY,
+    loan_id INT NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
+    sequence INT NOT NULL,
+    due_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    principal NUMERIC NOT NULL,
+    interest NUMERIC NOT NULL,
+    amount NUMERIC NOT NULL,
+    outstanding_balance NUMERIC NOT NULL,
+    paid_principal NUMERIC NOT NULL DEFAULT 0,
+    paid_interest NUMERIC NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'pending',
+    paid_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (loan_id, sequence)
+);
+
+CREATE TABLE repayments (
+    id SERIAL PRIMARY KEY,
+    loan_id INT NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
+    payer_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount NUMERIC NOT NULL,
+    principal NUMERIC NOT NULL,
+    interest NUMERIC NOT NULL,
+    platform_fee NUMERIC NOT NULL DEFAULT 0,
+    paid_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE repayment_allocations (
+    id SERIAL PRIMARY KEY,
+    repayment_id INT NOT NULL REFERENCES repayments(id) ON DELETE CASCADE,
+    installment_id INT NOT NULL REFERENCES installments(id) ON DELETE CASCADE,
+    principal NUMERIC NOT NULL,
+    interest NUMERIC NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE payouts (
+    id SERIAL PRIMARY KEY,
+    repayment_id INT NOT NULL REFERENCES repayments(id) ON DELETE CASCADE,
+    loan_id INT NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
+    investment_id INT NOT NULL REFERENCES investments(id) ON DELETE CASCADE,
+    investor_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    principal NUMERIC NOT NULL,
+    return NUMERIC NOT NULL,
+    amount NUMERIC NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    loan_id INT NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipient TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE loan_events (
+    id SERIAL PRIMARY KEY,
+    loan_id INT NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    actor_id INT NOT NULL,
+    actor_role TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    payload JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_loan_events_loan_id ON loan_events(loan_id);
@@ -0,0 +1,85 @@
+package statemachine
+
+import "loan-service/utils/constants"
+
+const (
+	EventPropose  Event = "propose"
+	EventApprove  Event = "approve"
+	EventReject   Event = "reject"
+	EventInvest   Event = "invest"
+	EventDisburse Event = "disburse"
+	EventRepay    Event = "repay"
+	EventPayOff   Event = "pay_off"
+	EventDefault  Event = "default"
+	EventCancel   Event = "cancel"
+	EventExpire   Event = "expire"
+)
+
+// LoanTransitions is the lifecycle of a loan, see the state diagram in the README
+var LoanTransitions = []Transition{
+	{
+		Event: EventPropose,
+		From:  []constants.LoanStatus{None},
+		To:    constants.StatusProposed,
+		Roles: []constants.UserRole{constants.RoleBorrower},
+	},
+	{
+		Event: EventApprove,
+		From:  []constants.LoanStatus{constants.StatusProposed},
+		To:    constants.StatusApproved,
+		Roles: []constants.UserRole{constants.RoleValidator, constants.RoleSupervisor},
+	},
+	{
+		Event: EventReject,
+		From:  []constants.LoanStatus{constants.StatusProposed},
+		To:    constants.StatusRejected,
+		Roles: []constants.UserRole{constants.RoleValidator},
+	},
+	{
+		Event: EventInvest,
+		From:  []constants.LoanStatus{constants.StatusApproved},
+		To:    constants.StatusInvested,
+		Roles: []constants.UserRole{constants.RoleInvestor},
+	},
+	{
+		Event: EventDisburse,
+		From:  []constants.LoanStatus{constants.StatusInvested},
+		To:    constants.StatusDisbursed,
+		Roles: []constants.UserRole{constants.RoleDisburser},
+	},
+	{
+		Event: EventRepay,
+		From:  []constants.LoanStatus{constants.StatusDisbursed},
+		To:    constants.StatusRepaying,
+		Roles: []constants.UserRole{constants.RoleBorrower},
+	},
+	{
+		Event: EventPayOff,
+		From:  []constants.LoanStatus{constants.StatusDisbursed, constants.StatusRepaying, constants.StatusDefaulted},
+		To:    constants.StatusPaidOff,
+		Roles: []constants.UserRole{constants.RoleBorrower},
+	},
+	{
+		Event: EventDefault,
+		From:  []constants.LoanStatus{constants.StatusDisbursed, constants.StatusRepaying},
+		To:    constants.StatusDefaulted,
+		Roles: []constants.UserRole{},
+	},
+	{
+		Event: EventCancel,
+		From:  []constants.LoanStatus{constants.StatusProposed, constants.StatusApproved},
+		To:    constants.StatusCancelled,
+		Roles: []constants.UserRole{constants.RoleBorrower},
+	},
+	{
+		Event: EventExpire,
+		From:  []constants.LoanStatus{constants.StatusApproved},
+		To:    constants.StatusExpired,
+		Roles: []constants.UserRole{constants.RoleSystem},
+	},
+}
+
+// NewLoanMachine returns a machine for the loan lifecycle without any hook registered
+func NewLoanMachine() *Machine {
+	return New(LoanTransitions)
+}
@@ -0,0 +1,132 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// None is the status of a loan that has not been stored yet
+const None constants.LoanStatus = ""
+
+type Event string
+
+// Transition declares a status change, the event can only be fired from one of the From statuses
+// by one of the Roles. Admin is allowed to fire every event.
+type Transition struct {
+	Event Event
+	From  []constants.LoanStatus
+	To    constants.LoanStatus
+	Roles []constants.UserRole
+}
+
+// Change describes a transition being applied, hooks run inside the transaction of the change.
+// Payload holds the details of the action that triggered the transition.
+type Change struct {
+	Tx      *gorm.DB
+	Loan    *entity.Loan
+	Event   Event
+	From    constants.LoanStatus
+	To      constants.LoanStatus
+	Actor   entity.Actor
+	Payload interface{}
+}
+
+// Hook is called around a transition, an error from a hook aborts the transition
+type Hook func(ctx context.Context, change Change) error
+
+type Machine struct {
+	transitions map[Event]Transition
+	guards      map[Event][]Hook
+	afters      map[Event][]Hook
+	afterEach   []Hook
+}
+
+func New(transitions []Transition) *Machine {
+	m := &Machine{
+		transitions: make(map[Event]Transition, len(transitions)),
+		guards:      map[Event][]Hook{},
+		afters:      map[Event][]Hook{},
+	}
+	for _, t := range transitions {
+		m.transitions[t.Event] = t
+	}
+	return m
+}
+
+// Guard registers a hook that must pass before the status of the loan changes
+func (m *Machine) Guard(event Event, hook Hook) {
+	m.guards[event] = append(m.guards[event], hook)
+}
+
+// After registers a hook that runs once the new status of the loan is saved
+func (m *Machine) After(event Event, hook Hook) {
+	m.afters[event] = append(m.afters[event], hook)
+}
+
+// AfterEach registers a hook that runs after every transition
+func (m *Machine) AfterEach(hook Hook) {
+	m.afterEach = append(m.afterEach, hook)
+}
+
+// From returns the statuses the event can be fired from
+func (m *Machine) From(event Event) []constants.LoanStatus {
+	return slices.Clone(m.transitions[event].From)
+}
+
+// Can checks whether the role is allowed to fire the event on a loan with the given status
+func (m *Machine) Can(event Event, from constants.LoanStatus, role constants.UserRole) error {
+	t, ok := m.transitions[event]
+	if !ok || !slices.Contains(t.From, from) {
+		return errors.New(errs.ErrInvalidTransition)
+	}
+	if role != constants.RoleAdmin && !slices.Contains(t.Roles, role) {
+		return errors.New(errs.ErrUnauthorizedAction)
+	}
+	return nil
+}
+
+// Fire applies the event to the loan: guards are checked, the new status is saved with tx and the after hooks run.
+// The loan keeps its previous status when the transition fails.
+func (m *Machine) Fire(ctx context.Context, tx *gorm.DB, loan *entity.Loan, event Event, actor entity.Actor, payload interface{}) error {
+	if err := m.Can(event, loan.Status, actor.Role); err != nil {
+		return err
+	}
+
+	change := Change{
+		Tx:      tx,
+		Loan:    loan,
+		Event:   event,
+		From:    loan.Status,
+		To:      m.transitions[event].To,
+		Actor:   actor,
+		Payload: payload,
+	}
+	for _, guard := range m.guards[event] {
+		if err := guard(ctx, change); err != nil {
+			return err
+		}
+	}
+
+	loan.Status = change.To
+	if err := tx.Save(loan).Error; err != nil {
+		loan.Status = change.From
+		return err
+	}
+
+	// the hooks are copied so concurrent transitions never append into the shared slices
+	hooks := make([]Hook, 0, len(m.afters[event])+len(m.afterEach))
+	hooks = append(append(hooks, m.afters[event]...), m.afterEach...)
+	for _, hook := range hooks {
+		if err := hook(ctx, change); err != nil {
+			loan.Status = change.From
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package statemachine_test
+
+import (
+	"context"
+	"fmt"
+	"loan-service/entity"
+	"loan-service/statemachine"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dialector := postgres.New(postgres.Config{
+		Conn:       sqlDB,
+		DriverName: "postgres",
+	})
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	assert.NoError(t, err)
+
+	return db, mock
+}
+
+func TestMachine_Can(t *testing.T) {
+	m := statemachine.NewLoanMachine()
+	tests := []struct {
+		name    string
+		event   statemachine.Event
+		from    constants.LoanStatus
+		role    constants.UserRole
+		wantErr error
+	}{
+		{
+			name:  "Validator approves proposed loan",
+			event: statemachine.EventApprove,
+			from:  constants.StatusProposed,
+			role:  constants.RoleValidator,
+		},
+		{
+			name:  "Admin can fire any event",
+			event: statemachine.EventDisburse,
+			from:  constants.StatusInvested,
+			role:  constants.RoleAdmin,
+		},
+		{
+			name:    "Wrong role",
+			event:   statemachine.EventApprove,
+			from:    constants.StatusProposed,
+			role:    constants.RoleInvestor,
+			wantErr: fmt.Errorf(errs.ErrUnauthorizedAction),
+		},
+		{
+			name:    "Wrong source status",
+			event:   statemachine.EventApprove,
+			from:    constants.StatusRejected,
+			role:    constants.RoleValidator,
+			wantErr: fmt.Errorf(errs.ErrInvalidTransition),
+		},
+		{
+			name:    "Default is admin only",
+			event:   statemachine.EventDefault,
+			from:    constants.StatusRepaying,
+			role:    constants.RoleBorrower,
+			wantErr: fmt.Errorf(errs.ErrUnauthorizedAction),
+		},
+		{
+			name:    "Invested loan cannot be cancelled",
+			event:   statemachine.EventCancel,
+			from:    constants.StatusInvested,
+			role:    constants.RoleBorrower,
+			wantErr: fmt.Errorf(errs.ErrInvalidTransition),
+		},
+		{
+			name:  "System expires approved loan",
+			event: statemachine.EventExpire,
+			from:  constants.StatusApproved,
+			role:  constants.RoleSystem,
+		},
+		{
+			name:    "Users cannot expire loans",
+			event:   statemachine.EventExpire,
+			from:    constants.StatusApproved,
+			role:    constants.RoleBorrower,
+			wantErr: fmt.Errorf(errs.ErrUnauthorizedAction),
+		},
+		{
+			name:    "Unknown event",
+			event:   statemachine.Event("archive"),
+			from:    constants.StatusPaidOff,
+			role:    constants.RoleAdmin,
+			wantErr: fmt.Errorf(errs.ErrInvalidTransition),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := m.Can(tt.event, tt.from, tt.role)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMachine_From(t *testing.T) {
+	m := statemachine.NewLoanMachine()
+	assert.Equal(t, []constants.LoanStatus{constants.StatusProposed}, m.From(statemachine.EventApprove))
+	assert.Equal(t,
+		[]constants.LoanStatus{constants.StatusDisbursed, constants.StatusRepaying, constants.StatusDefaulted},
+		m.From(statemachine.EventPayOff))
+	assert.Empty(t, m.From(statemachine.Event("archive")))
+}
+
+func TestMachine_Fire(t *testing.T) {
+	validator := entity.Actor{ID: 2, Role: constants.RoleValidator}
+	tests := []struct {
+		name       string
+		loan       entity.Loan
+		actor      entity.Actor
+		guardErr   error
+		mockFunc   func(mockSql sqlmock.Sqlmock)
+		wantStatus constants.LoanStatus
+		wantHooks  []string
+		wantErr    error
+	}{
+		{
+			name:  "Success",
+			loan:  entity.Loan{DBCommon: entity.DBCommon{ID: 1}, Status: constants.StatusProposed},
+			actor: validator,
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectBegin()
+				mockSql.ExpectExec(`UPDATE "loans"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						constants.StatusApproved,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						1,
+					).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mockSql.ExpectCommit()
+			},
+			wantStatus: constants.StatusApproved,
+			wantHooks:  []string{"guard", "after", "after each"},
+		},
+		{
+			name:       "Guard rejects the transition",
+			loan:       entity.Loan{DBCommon: entity.DBCommon{ID: 1}, Status: constants.StatusProposed},
+			actor:      validator,
+			guardErr:   fmt.Errorf("guard failed"),
+			wantStatus: constants.StatusProposed,
+			wantHooks:  []string{"guard"},
+			wantErr:    fmt.Errorf("guard failed"),
+		},
+		{
+			name:       "Invalid transition",
+			loan:       entity.Loan{DBCommon: entity.DBCommon{ID: 1}, Status: constants.StatusInvested},
+			actor:      validator,
+			wantStatus: constants.StatusInvested,
+			wantErr:    fmt.Errorf(errs.ErrInvalidTransition),
+		},
+		{
+			name:  "Save error keeps the previous status",
+			loan:  entity.Loan{DBCommon: entity.DBCommon{ID: 1}, Status: constants.StatusProposed},
+			actor: validator,
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectBegin()
+				mockSql.ExpectExec(`UPDATE "loans"`).WillReturnError(fmt.Errorf("DB error"))
+				mockSql.ExpectRollback()
+			},
+			wantStatus: constants.StatusProposed,
+			wantHooks:  []string{"guard"},
+			wantErr:    fmt.Errorf("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+
+			var hooks []string
+			m := statemachine.NewLoanMachine()
+			m.Guard(statemachine.EventApprove, func(ctx context.Context, change statemachine.Change) error {
+				hooks = append(hooks, "guard")
+				assert.Equal(t, constants.StatusProposed, change.From)
+				assert.Equal(t, constants.StatusApproved, change.To)
+				return tt.guardErr
+			})
+			m.After(statemachine.EventApprove, func(ctx context.Context, change statemachine.Change) error {
+				hooks = append(hooks, "after")
+				assert.Equal(t, constants.StatusApproved, change.Loan.Status)
+				return nil
+			})
+			m.After(statemachine.EventReject, func(ctx context.Context, change statemachine.Change) error {
+				hooks = append(hooks, "reject")
+				return nil
+			})
+			m.AfterEach(func(ctx context.Context, change statemachine.Change) error {
+				hooks = append(hooks, "after each")
+				assert.Equal(t, tt.actor, change.Actor)
+				return nil
+			})
+
+			loan := tt.loan
+			err := m.Fire(context.Background(), db, &loan, statemachine.EventApprove, tt.actor, nil)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantStatus, loan.Status)
+			assert.Equal(t, tt.wantHooks, hooks)
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"loan-service/agreement"
+	"loan-service/entity"
+	"loan-service/statemachine"
+	"loan-service/utils/constants"
+	"loan-service/utils/logger"
+	"slices"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// writeLoanAgreement renders the agreement of a new loan with the template version stored on the loan and returns
+// its link. The schedule in the agreement starts from the proposal date, the final one is fixed on disbursement.
+func (u *LoanUsecase) writeLoanAgreement(ctx context.Context, tx *gorm.DB, loan entity.Loan) (string, error) {
+	var borrower entity.User
+	if err := tx.First(&borrower, "id = ?", loan.BorrowerID).Error; err != nil {
+		logger.Error("Failed to fetch borrower of loan agreement", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return "", err
+	}
+	schedule, err := GenerateInstallments(loan, loan.CreatedAt)
+	if err != nil {
+		return "", err
+	}
+
+	var pdf bytes.Buffer
+	if err := agreement.RenderLoan(&pdf, loan.AgreementVersion, agreement.NewLoanData(loan, borrower, schedule)); err != nil {
+		logger.Error("Failed to render loan agreement", zap.Uint("loanID", loan.ID), zap.String("version", loan.AgreementVersion), zap.Error(err))
+		return "", errors.New("failed to create PDF document")
+	}
+	document, err := u.documents.SaveDocument(ctx, tx, entity.Document{
+		Kind:     constants.DocumentLoanAgreement,
+		Name:     fmt.Sprintf("loan_agreement_%d.pdf", loan.ID),
+		MimeType: "application/pdf",
+		OwnerID:  loan.BorrowerID,
+		LoanID:   &loan.ID,
+	}, pdf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return u.documents.Link(*document), nil
+}
+
+// writeInvestmentAgreements runs once a loan is fully funded and issues every active investment its own agreement,
+// the share of each investor is final at that point since investments can no longer be cancelled
+func (u *LoanUsecase) writeInvestmentAgreements(ctx context.Context, change statemachine.Change) error {
+	loan := *change.Loan
+	var investments []entity.Investment
+	if err := change.Tx.Where("loan_id = ? AND status = ?", loan.ID, constants.InvestmentActive).Order("id").Find(&investments).Error; err != nil {
+		logger.Error("Failed to fetch investments for agreements", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return err
+	}
+	investorIDs := []uint{}
+	for _, inv := range investments {
+		if !slices.Contains(investorIDs, inv.InvestorID) {
+			investorIDs = append(investorIDs, inv.InvestorID)
+		}
+	}
+	var users []entity.User
+	if err := change.Tx.Where("id IN ?", investorIDs).Find(&users).Error; err != nil {
+		logger.Error("Failed to fetch investors for agreements", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return err
+	}
+	investors := make(map[uint]entity.User, len(users))
+	for _, user := range users {
+		investors[user.ID] = user
+	}
+
+	for _, investment := range investments {
+		var pdf bytes.Buffer
+		data := agreement.NewInvestmentData(loan, investment, investors[investment.InvestorID])
+		if err := agreement.RenderInvestment(&pdf, agreement.CurrentInvestmentVersion, data); err != nil {
+			logger.Error("Failed to render investment agreement", zap.Uint("investmentID", investment.ID), zap.Error(err))
+			return errors.New("failed to create PDF document")
+		}
+		document, err := u.documents.SaveDocument(ctx, change.Tx, entity.Document{
+			Kind:     constants.DocumentInvestmentAgreement,
+			Name:     fmt.Sprintf("investment_agreement_%d.pdf", investment.ID),
+			MimeType: "application/pdf",
+			OwnerID:  investment.InvestorID,
+			LoanID:   &loan.ID,
+		}, pdf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		if err := change.Tx.Model(&investment).Updates(map[string]interface{}{
+			"agreement_link":    u.documents.Link(*document),
+			"agreement_version": agreement.CurrentInvestmentVersion,
+		}).Error; err != nil {
+			logger.Error("Failed to save investment agreement link", zap.Uint("investmentID", investment.ID), zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	"loan-service/utils/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type BorrowerUsecase struct {
+	db *gorm.DB
+}
+
+func NewBorrowerUsecase(db *gorm.DB) *BorrowerUsecase {
+	return &BorrowerUsecase{db: db}
+}
+
+// GetLoans returns every loan of the borrower from the most recent one
+func (u *BorrowerUsecase) GetLoans(borrowerID uint) ([]entity.BorrowerLoan, error) {
+	loans := []entity.BorrowerLoan{}
+	if err := u.db.Table("loans").
+		Select("loans.id, loans.created_at, loans.principal, loans.currency, loans.rate, loans.tenor, loans.frequency, loans.status, "+
+			"loans.agreement_link, loan_approvals.reject_reason, COALESCE(SUM(investments.amount), 0) AS funded_amount").
+		Joins("LEFT JOIN loan_approvals ON loan_approvals.loan_id = loans.id").
+		Joins("LEFT JOIN investments ON investments.loan_id = loans.id AND investments.status = ?", constants.InvestmentActive).
+		Where("loans.borrower_id = ?", borrowerID).
+		Group("loans.id, loan_approvals.reject_reason").
+		Order("loans.created_at DESC, loans.id DESC").
+		Scan(&loans).Error; err != nil {
+		logger.Error("Failed to fetch borrower loans", zap.Uint("borrowerID", borrowerID), zap.Error(err))
+		return nil, err
+	}
+	if len(loans) == 0 {
+		return loans, nil
+	}
+
+	loanIDs := make([]uint, 0, len(loans))
+	for _, loan := range loans {
+		loanIDs = append(loanIDs, loan.ID)
+	}
+
+	var installments []entity.Installment
+	if err := u.db.Where("loan_id IN ? AND status <> ?", loanIDs, constants.InstallmentPaid).
+		Order("loan_id, sequence").Find(&installments).Error; err != nil {
+		logger.Error("Failed to fetch borrower installments", zap.Uint("borrowerID", borrowerID), zap.Error(err))
+		return nil, err
+	}
+
+	// installments are sorted by sequence, the first open one of each loan is the next due
+	next := map[uint]*entity.Installment{}
+	for i := range installments {
+		if _, ok := next[installments[i].LoanID]; !ok {
+			next[installments[i].LoanID] = &installments[i]
+		}
+	}
+
+	for i := range loans {
+		loan := &loans[i]
+		loan.PercentFunded = percentFunded(loan.FundedAmount, loan.Principal)
+		loan.NextInstallment = next[loan.ID]
+	}
+
+	return loans, nil
+}
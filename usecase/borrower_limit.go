@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"fmt"
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/logger"
+	"loan-service/utils/money"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// activeLoanStatuses are the statuses of the loans a borrower still owes or may still owe, a defaulted loan stays
+// outstanding until it is written off
+var activeLoanStatuses = []constants.LoanStatus{
+	constants.StatusProposed,
+	constants.StatusApproved,
+	constants.StatusInvested,
+	constants.StatusDisbursed,
+	constants.StatusRepaying,
+	constants.StatusDefaulted,
+}
+
+// checkBorrowerLimits enforces the borrower limits of the policy on a new proposal, the principal of the proposal
+// counts toward the outstanding principal of its currency
+func (u *LoanUsecase) checkBorrowerLimits(tx *gorm.DB, borrowerID uint, principal decimal.Decimal, currency money.Currency) error {
+	if u.policy.MaxBorrowerActiveLoans > 0 {
+		var active int64
+		if err := tx.Model(&entity.Loan{}).
+			Where("borrower_id = ? AND status IN ?", borrowerID, activeLoanStatuses).
+			Count(&active).Error; err != nil {
+			logger.Error("Failed to count borrower active loans", zap.Uint("borrowerID", borrowerID), zap.Error(err))
+			return err
+		}
+		if active >= int64(u.policy.MaxBorrowerActiveLoans) {
+			return &errs.LimitExceededError{
+				Message: errs.ErrBorrowerActiveLoansExceeded,
+				Current: fmt.Sprintf("%d active loans", active),
+				Limit:   fmt.Sprintf("%d active loans", u.policy.MaxBorrowerActiveLoans),
+			}
+		}
+	}
+
+	limit, ok := u.policy.MaxBorrowerOutstanding[currency]
+	if !ok || !limit.IsPositive() {
+		return nil
+	}
+	outstanding, err := borrowerOutstanding(tx, borrowerID, currency)
+	if err != nil {
+		logger.Error("Failed to fetch borrower outstanding principal", zap.Uint("borrowerID", borrowerID), zap.Error(err))
+		return err
+	}
+	if outstanding.Add(principal).GreaterThan(limit) {
+		return &errs.LimitExceededError{
+			Message: errs.ErrBorrowerOutstandingExceeded,
+			Current: formatAmount(outstanding, currency),
+			Limit:   formatAmount(limit, currency),
+		}
+	}
+	return nil
+}
+
+// borrowerOutstanding is the principal of the active loans of a borrower in a currency that is not repaid yet
+func borrowerOutstanding(tx *gorm.DB, borrowerID uint, currency money.Currency) (decimal.Decimal, error) {
+	var principal, repaid decimal.Decimal
+	if err := tx.Table("loans").
+		Select("COALESCE(SUM(loans.principal), 0)").
+		Where("loans.borrower_id = ? AND loans.currency = ? AND loans.status IN ?", borrowerID, currency, activeLoanStatuses).
+		Scan(&principal).Error; err != nil {
+		return decimal.Zero, err
+	}
+	if err := tx.Table("installments").
+		Select("COALESCE(SUM(installments.paid_principal), 0)").
+		Joins("JOIN loans ON loans.id = installments.loan_id").
+		Where("loans.borrower_id = ? AND loans.currency = ? AND loans.status IN ?", borrowerID, currency, activeLoanStatuses).
+		Scan(&repaid).Error; err != nil {
+		return decimal.Zero, err
+	}
+	return principal.Sub(repaid), nil
+}
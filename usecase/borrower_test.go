@@ -0,0 +1,111 @@
+package usecase_test
+
+import (
+	"fmt"
+	"loan-service/entity"
+	"loan-service/usecase"
+	"loan-service/utils/constants"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBorrowerUsecase_GetLoans(t *testing.T) {
+	borrowerID := uint(1)
+	rejectReason := "Incomplete documents"
+	tests := []struct {
+		name     string
+		mockFunc func(mockSql sqlmock.Sqlmock)
+		want     []entity.BorrowerLoan
+		wantErr  error
+	}{
+		{
+			name: "GetLoans_Success",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT loans.id, loans.created_at, loans.principal, loans.currency, loans.rate, loans.tenor, loans.frequency, loans.status, `+
+						`loans.agreement_link, loan_approvals.reject_reason, COALESCE(SUM(investments.amount), 0) AS funded_amount FROM "loans" `+
+						`LEFT JOIN loan_approvals ON loan_approvals.loan_id = loans.id LEFT JOIN investments ON investments.loan_id = loans.id AND investments.status = $1 `+
+						`WHERE loans.borrower_id = $2 GROUP BY loans.id, loan_approvals.reject_reason ORDER BY loans.created_at DESC, loans.id DESC`)).
+					WithArgs(constants.InvestmentActive, borrowerID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "status", "reject_reason", "funded_amount"}).
+						AddRow(3, 1000, constants.StatusRepaying, nil, 1000).
+						AddRow(2, 400, constants.StatusApproved, nil, 100).
+						AddRow(1, 500, constants.StatusRejected, rejectReason, 0))
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "installments" WHERE loan_id IN ($1,$2,$3) AND status <> $4 ORDER BY loan_id, sequence`)).
+					WithArgs(3, 2, 1, constants.InstallmentPaid).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "sequence", "amount", "status"}).
+						AddRow(12, 3, 2, 90, constants.InstallmentPartial).
+						AddRow(13, 3, 3, 90, constants.InstallmentPending))
+			},
+			want: []entity.BorrowerLoan{
+				{
+					ID:            3,
+					Principal:     decimal.NewFromInt(1000),
+					Status:        constants.StatusRepaying,
+					FundedAmount:  decimal.NewFromInt(1000),
+					PercentFunded: decimal.NewFromInt(100),
+					NextInstallment: &entity.Installment{
+						DBCommon: entity.DBCommon{ID: 12},
+						LoanID:   3,
+						Sequence: 2,
+						Amount:   decimal.NewFromInt(90),
+						Status:   constants.InstallmentPartial,
+					},
+				},
+				{
+					ID:            2,
+					Principal:     decimal.NewFromInt(400),
+					Status:        constants.StatusApproved,
+					FundedAmount:  decimal.NewFromInt(100),
+					PercentFunded: decimal.NewFromInt(25),
+				},
+				{
+					ID:           1,
+					Principal:    decimal.NewFromInt(500),
+					Status:       constants.StatusRejected,
+					RejectReason: &rejectReason,
+				},
+			},
+		},
+		{
+			name: "GetLoans_Success_NoLoans",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`FROM "loans"`)).
+					WithArgs(constants.InvestmentActive, borrowerID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			want: []entity.BorrowerLoan{},
+		},
+		{
+			name: "GetLoans_Failure_DBError",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`FROM "loans"`)).
+					WillReturnError(fmt.Errorf("DB error"))
+			},
+			wantErr: fmt.Errorf("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			u := usecase.NewBorrowerUsecase(db)
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+			got, err := u.GetLoans(borrowerID)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assertSameJSON(t, tt.want, got)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"loan-service/entity"
+	"loan-service/statemachine"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CancelLoan withdraws a loan that is not invested yet, the partial investments of an approved loan are voided
+func (u *LoanUsecase) CancelLoan(ctx context.Context, cancelRequest entity.RequestCancelLoan, borrower entity.Actor) (*entity.Loan, error) {
+	unlock, err := u.lockLoan(ctx, cancelRequest.LoanID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tx := u.db.Begin(&sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	defer tx.Rollback()
+
+	var loan entity.Loan
+	if err := tx.First(&loan, "id = ? AND status IN ?", cancelRequest.LoanID, u.machine.From(statemachine.EventCancel)).Error; err != nil {
+		logger.Error("Failed to find loan to cancel", zap.Uint("loanID", cancelRequest.LoanID), zap.Error(err))
+		return nil, errors.New(errs.ErrLoanNotCancellable)
+	}
+
+	if err := u.machine.Fire(ctx, tx, &loan, statemachine.EventCancel, borrower, cancelRequest); err != nil {
+		logger.Error("Failed to cancel loan", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		logger.Error("Failed to commit loan cancellation", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("Loan cancelled", zap.Uint("loanID", loan.ID), zap.Uint("borrowerID", borrower.ID))
+
+	return &loan, nil
+}
+
+// CancelInvestment undoes an investment of an approved loan within the grace period
+func (u *LoanUsecase) CancelInvestment(ctx context.Context, cancelRequest entity.RequestCancelInvestment, investor entity.Actor) (*entity.Investment, error) {
+	var investment entity.Investment
+	if err := u.db.First(&investment, "id = ?", cancelRequest.InvestmentID).Error; err != nil {
+		logger.Error("Failed to find investment to cancel", zap.Uint("investmentID", cancelRequest.InvestmentID), zap.Error(err))
+		return nil, errors.New(errs.ErrInvestmentNotCancellable)
+	}
+
+	unlock, err := u.lockLoan(ctx, investment.LoanID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tx := u.db.Begin(&sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	defer tx.Rollback()
+
+	if err := tx.First(&investment, "id = ? AND status = ?", cancelRequest.InvestmentID, constants.InvestmentActive).Error; err != nil {
+		return nil, errors.New(errs.ErrInvestmentNotCancellable)
+	}
+	if investor.Role != constants.RoleAdmin && investment.InvestorID != investor.ID {
+		return nil, errors.New(errs.ErrUnauthorizedAction)
+	}
+	if time.Since(investment.CreatedAt) > u.policy.InvestmentGracePeriod {
+		return nil, errors.New(errs.ErrInvestmentGraceExpired)
+	}
+
+	var loan entity.Loan
+	if err := tx.First(&loan, "id = ? AND status = ?", investment.LoanID, constants.StatusApproved).Error; err != nil {
+		return nil, errors.New(errs.ErrInvestmentNotCancellable)
+	}
+
+	if err := tx.Model(&investment).Update("status", constants.InvestmentCancelled).Error; err != nil {
+		logger.Error("Failed to cancel investment", zap.Uint("investmentID", investment.ID), zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		logger.Error("Failed to commit investment cancellation", zap.Uint("investmentID", investment.ID), zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("Investment cancelled", zap.Uint("loanID", loan.ID), zap.Uint("investmentID", investment.ID))
+
+	return &investment, nil
+}
+
+// requireLoanOwner guards the cancel transition, only the borrower of the loan can withdraw it
+func requireLoanOwner(ctx context.Context, change statemachine.Change) error {
+	if change.Actor.Role != constants.RoleAdmin && change.Loan.BorrowerID != change.Actor.ID {
+		return errors.New(errs.ErrUnauthorizedAction)
+	}
+	return nil
+}
+
+// voidInvestments releases the investments of a loan that will not be disbursed, the investors are refunded
+// the voided amount
+func voidInvestments(ctx context.Context, change statemachine.Change) error {
+	if err := change.Tx.Model(&entity.Investment{}).
+		Where("loan_id = ? AND status = ?", change.Loan.ID, constants.InvestmentActive).
+		Update("status", constants.InvestmentVoided).Error; err != nil {
+		logger.Error("Failed to void loan investments", zap.Uint("loanID", change.Loan.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
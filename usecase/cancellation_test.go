@@ -0,0 +1,259 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loan-service/entity"
+	"loan-service/statemachine"
+	"loan-service/usecase"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestLoanUsecase_CancelLoan(t *testing.T) {
+	loanID := uint(1)
+	borrowerID := uint(2)
+	lockKey := fmt.Sprintf("event_lock:%d", loanID)
+	borrower := entity.Actor{ID: borrowerID, Role: constants.RoleBorrower}
+
+	expectLoan := func(mockSql sqlmock.Sqlmock, ownerID uint, status constants.LoanStatus) {
+		mockSql.ExpectBegin()
+		mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans" WHERE id = $1 AND status IN ($2,$3)`)).
+			WithArgs(loanID, constants.StatusProposed, constants.StatusApproved, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "borrower_id", "principal", "status"}).
+				AddRow(loanID, ownerID, 1000, status))
+	}
+	expectCancel := func(mockSql sqlmock.Sqlmock, from constants.LoanStatus, voided int64) {
+		mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "loans"`)).
+			WithArgs(
+				sqlmock.AnyArg(), sqlmock.AnyArg(), borrowerID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusCancelled, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID,
+			).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "investments" SET "status"=$1,"updated_at"=$2 WHERE loan_id = $3 AND status = $4`)).
+			WithArgs(constants.InvestmentVoided, sqlmock.AnyArg(), loanID, constants.InvestmentActive).
+			WillReturnResult(sqlmock.NewResult(0, voided))
+		expectLoanEvent(mockSql, loanID, statemachine.EventCancel, from, constants.StatusCancelled, borrower)
+	}
+
+	tests := []struct {
+		name     string
+		actor    entity.Actor
+		mockFunc func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock)
+		wantErr  error
+	}{
+		{
+			name:  "CancelLoan_Success_Proposed",
+			actor: borrower,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+				expectLoan(mockSql, borrowerID, constants.StatusProposed)
+				expectCancel(mockSql, constants.StatusProposed, 0)
+				mockSql.ExpectCommit()
+			},
+		},
+		{
+			name:  "CancelLoan_Success_ApprovedVoidsInvestments",
+			actor: borrower,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+				expectLoan(mockSql, borrowerID, constants.StatusApproved)
+				expectCancel(mockSql, constants.StatusApproved, 2)
+				mockSql.ExpectCommit()
+			},
+		},
+		{
+			name:  "CancelLoan_Failure_NotOwner",
+			actor: borrower,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+				expectLoan(mockSql, 9, constants.StatusApproved)
+				mockSql.ExpectRollback()
+			},
+			wantErr: errors.New(errs.ErrUnauthorizedAction),
+		},
+		{
+			name:  "CancelLoan_Failure_NotCancellable",
+			actor: borrower,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans"`)).
+					WithArgs(loanID, constants.StatusProposed, constants.StatusApproved, 1).
+					WillReturnError(gorm.ErrRecordNotFound)
+				mockSql.ExpectRollback()
+			},
+			wantErr: errors.New(errs.ErrLoanNotCancellable),
+		},
+		{
+			name:  "CancelLoan_Failure_VoidInvestments",
+			actor: borrower,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+				expectLoan(mockSql, borrowerID, constants.StatusApproved)
+				mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "loans"`)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "investments"`)).
+					WillReturnError(fmt.Errorf("DB error on voiding investments"))
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf("DB error on voiding investments"),
+		},
+		{
+			name:  "CancelLoan_Failure_Busy",
+			actor: borrower,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(false)
+			},
+			wantErr: errors.New(errs.ErrBusySystem),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			redis, mockRedis := redismock.NewClientMock()
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, usecase.LoanPolicy{})
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql, mockRedis)
+			}
+
+			got, err := u.CancelLoan(context.Background(), entity.RequestCancelLoan{LoanID: loanID, Reason: "No longer needed"}, tt.actor)
+			if tt.wantErr != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, constants.StatusCancelled, got.Status)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+			assert.NoError(t, mockRedis.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLoanUsecase_CancelInvestment(t *testing.T) {
+	loanID := uint(1)
+	investmentID := uint(6)
+	investorID := uint(3)
+	lockKey := fmt.Sprintf("event_lock:%d", loanID)
+	investor := entity.Actor{ID: investorID, Role: constants.RoleInvestor}
+	policy := usecase.LoanPolicy{InvestmentGracePeriod: time.Hour}
+
+	investmentRows := func(investedAt time.Time, ownerID uint) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "created_at", "loan_id", "investor_id", "amount", "status"}).
+			AddRow(investmentID, investedAt, loanID, ownerID, 200, constants.InvestmentActive)
+	}
+	expectInvestment := func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock, investedAt time.Time, ownerID uint) {
+		mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "investments" WHERE id = $1`)).
+			WithArgs(investmentID, 1).
+			WillReturnRows(investmentRows(investedAt, ownerID))
+		mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+		mockRedis.ExpectDel(lockKey).SetVal(1)
+		mockSql.ExpectBegin()
+		mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "investments" WHERE (id = $1 AND status = $2)`)).
+			WithArgs(investmentID, constants.InvestmentActive, investmentID, 1).
+			WillReturnRows(investmentRows(investedAt, ownerID))
+	}
+
+	tests := []struct {
+		name     string
+		mockFunc func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock)
+		wantErr  error
+	}{
+		{
+			name: "CancelInvestment_Success",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				expectInvestment(mockSql, mockRedis, time.Now().Add(-time.Minute), investorID)
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans" WHERE id = $1 AND status = $2`)).
+					WithArgs(loanID, constants.StatusApproved, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "status"}).AddRow(loanID, 1000, constants.StatusApproved))
+				mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "investments" SET "status"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+					WithArgs(constants.InvestmentCancelled, sqlmock.AnyArg(), investmentID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mockSql.ExpectCommit()
+			},
+		},
+		{
+			name: "CancelInvestment_Failure_NotFound",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "investments"`)).
+					WithArgs(investmentID, 1).
+					WillReturnError(gorm.ErrRecordNotFound)
+			},
+			wantErr: errors.New(errs.ErrInvestmentNotCancellable),
+		},
+		{
+			name: "CancelInvestment_Failure_NotOwner",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				expectInvestment(mockSql, mockRedis, time.Now().Add(-time.Minute), 9)
+				mockSql.ExpectRollback()
+			},
+			wantErr: errors.New(errs.ErrUnauthorizedAction),
+		},
+		{
+			name: "CancelInvestment_Failure_GracePeriodExpired",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				expectInvestment(mockSql, mockRedis, time.Now().Add(-2*time.Hour), investorID)
+				mockSql.ExpectRollback()
+			},
+			wantErr: errors.New(errs.ErrInvestmentGraceExpired),
+		},
+		{
+			name: "CancelInvestment_Failure_LoanNotApproved",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				expectInvestment(mockSql, mockRedis, time.Now().Add(-time.Minute), investorID)
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans"`)).
+					WithArgs(loanID, constants.StatusApproved, 1).
+					WillReturnError(gorm.ErrRecordNotFound)
+				mockSql.ExpectRollback()
+			},
+			wantErr: errors.New(errs.ErrInvestmentNotCancellable),
+		},
+		{
+			name: "CancelInvestment_Failure_Busy",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "investments"`)).
+					WithArgs(investmentID, 1).
+					WillReturnRows(investmentRows(time.Now(), investorID))
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(false)
+			},
+			wantErr: errors.New(errs.ErrBusySystem),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			redis, mockRedis := redismock.NewClientMock()
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, policy)
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql, mockRedis)
+			}
+
+			got, err := u.CancelInvestment(context.Background(), entity.RequestCancelInvestment{InvestmentID: investmentID}, investor)
+			if tt.wantErr != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, constants.InvestmentCancelled, got.Status)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+			assert.NoError(t, mockRedis.ExpectationsWereMet())
+		})
+	}
+}
@@ -1,9 +1,17 @@
 package usecase_test
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"loan-service/entity"
+	"loan-service/statemachine"
+	"loan-service/utils/constants"
+	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -22,3 +30,122 @@ func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
 
 	return db, mock
 }
+
+// fakeNotifier records the queued notifications instead of writing them with the transaction
+type fakeNotifier struct {
+	invested []uint
+	expired  []uint
+	woken    int
+	err      error
+}
+
+func (f *fakeNotifier) QueueLoanInvested(ctx context.Context, tx *gorm.DB, loanID uint) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.invested = append(f.invested, loanID)
+	return nil
+}
+
+func (f *fakeNotifier) QueueLoanExpired(ctx context.Context, tx *gorm.DB, loanID uint) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.expired = append(f.expired, loanID)
+	return nil
+}
+
+func (f *fakeNotifier) Wake() {
+	f.woken++
+}
+
+// fakeDocuments keeps the saved documents in memory instead of the document store and the documents table,
+// the documents of a transaction that did not commit are moved to discarded
+type fakeDocuments struct {
+	saved     []entity.Document
+	discarded []entity.Document
+	err       error
+}
+
+func (f *fakeDocuments) TrackContent(ctx context.Context) (context.Context, func(committed bool)) {
+	tracked := len(f.saved)
+	return ctx, func(committed bool) {
+		if !committed {
+			f.discarded = append(f.discarded, f.saved[tracked:]...)
+			f.saved = f.saved[:tracked]
+		}
+	}
+}
+
+func (f *fakeDocuments) SaveDocument(ctx context.Context, tx *gorm.DB, document entity.Document, body []byte) (*entity.Document, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	document.ID = uint(len(f.saved) + 1)
+	document.Size = int64(len(body))
+	f.saved = append(f.saved, document)
+	return &document, nil
+}
+
+func (f *fakeDocuments) Link(document entity.Document) string {
+	return fmt.Sprintf("https://documents.test/%d", document.ID)
+}
+
+// assertLoanAgreementDocument checks the loan agreement was saved as the only document
+func assertLoanAgreementDocument(t *testing.T, documents *fakeDocuments, loanID, borrowerID uint) {
+	if assert.Len(t, documents.saved, 1) {
+		document := documents.saved[0]
+		assert.Equal(t, constants.DocumentLoanAgreement, document.Kind)
+		assert.Equal(t, fmt.Sprintf("loan_agreement_%d.pdf", loanID), document.Name)
+		assert.Equal(t, "application/pdf", document.MimeType)
+		assert.Equal(t, borrowerID, document.OwnerID)
+		assert.Equal(t, &loanID, document.LoanID)
+		assert.Positive(t, document.Size)
+	}
+}
+
+// expectAttachedDocument expects the lookup of a document uploaded for the loan
+func expectAttachedDocument(mockSql sqlmock.Sqlmock, documentID uint, kind constants.DocumentKind, loanID uint) {
+	mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "documents" WHERE id = $1 AND kind = $2 AND loan_id = $3`)).
+		WithArgs(documentID, kind, loanID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "loan_id"}).AddRow(documentID, kind, loanID))
+}
+
+func expectLoanEvent(mockSql sqlmock.Sqlmock, loanID uint, event statemachine.Event, from, to constants.LoanStatus, actor entity.Actor) {
+	mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "loan_events"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), loanID, string(event), from, to, actor.ID, actor.Role, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+}
+
+// expectBorrowerHistory expects the history lookup of the risk scorer for a borrower without earlier loans
+func expectBorrowerHistory(mockSql sqlmock.Sqlmock, loanID uint) {
+	mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT status, COUNT(*) AS count FROM "loans"`)).
+		WithArgs(sqlmock.AnyArg(), loanID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}))
+}
+
+// expectAgreementBorrower expects the borrower lookup of the loan agreement rendering
+func expectAgreementBorrower(mockSql sqlmock.Sqlmock, borrowerID uint) {
+	mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1`)).
+		WithArgs(borrowerID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role"}).
+			AddRow(borrowerID, "borrower", "borrower@example.com", 1))
+}
+
+func assertAmount(t *testing.T, want float64, got decimal.Decimal) {
+	assert.Equal(t, decimal.NewFromFloat(want).String(), got.String())
+}
+
+// assertSameJSON compares values holding decimals by their JSON form, since equal decimals may differ in scale
+func assertSameJSON(t *testing.T, want, got interface{}) {
+	wantJSON, err := json.Marshal(want)
+	assert.NoError(t, err)
+	gotJSON, err := json.Marshal(got)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(wantJSON), string(gotJSON))
+}
+
+// dec is a shorthand for decimal amounts in expected query arguments
+func dec(v float64) decimal.Decimal {
+	return decimal.NewFromFloat(v)
+}
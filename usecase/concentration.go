@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/logger"
+	"loan-service/utils/money"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// checkConcentration enforces the per-investor limits on a new investment. It runs inside the investment transaction
+// so the holdings it reads can not change before the investment is committed.
+func (u *LoanUsecase) checkConcentration(tx *gorm.DB, loan entity.Loan, investorID uint, amount decimal.Decimal) error {
+	if u.policy.MaxInvestorLoanShare.IsPositive() {
+		held := decimal.Zero
+		for _, inv := range loan.Investments {
+			if inv.InvestorID == investorID {
+				held = held.Add(inv.Amount)
+			}
+		}
+		limit := money.Round(loan.Principal.Mul(u.policy.MaxInvestorLoanShare).Div(hundred), loan.Currency)
+		if held.Add(amount).GreaterThan(limit) {
+			return &errs.LimitExceededError{
+				Message: errs.ErrInvestorLoanShareExceeded,
+				Current: formatAmount(held, loan.Currency),
+				Limit:   formatAmount(limit, loan.Currency),
+			}
+		}
+	}
+
+	limit, ok := u.policy.MaxBorrowerExposure[loan.Currency]
+	if !ok || !limit.IsPositive() {
+		return nil
+	}
+	exposure, err := investorExposure(tx, investorID, loan.BorrowerID, loan.Currency)
+	if err != nil {
+		logger.Error("Failed to fetch investor exposure", zap.Uint("investorID", investorID), zap.Uint("borrowerID", loan.BorrowerID), zap.Error(err))
+		return err
+	}
+	if exposure.Add(amount).GreaterThan(limit) {
+		return &errs.LimitExceededError{
+			Message: errs.ErrInvestorExposureExceeded,
+			Current: formatAmount(exposure, loan.Currency),
+			Limit:   formatAmount(limit, loan.Currency),
+		}
+	}
+	return nil
+}
+
+// investorExposure is the principal an investor has in the loans of a borrower that is not paid back yet, it is
+// computed the same way as the outstanding exposure of the portfolio
+func investorExposure(tx *gorm.DB, investorID, borrowerID uint, currency money.Currency) (decimal.Decimal, error) {
+	var invested, received decimal.Decimal
+	if err := tx.Table("investments").
+		Select("COALESCE(SUM(investments.amount), 0)").
+		Joins("JOIN loans ON loans.id = investments.loan_id").
+		Where("investments.investor_id = ? AND investments.status = ? AND loans.borrower_id = ? AND loans.currency = ?",
+			investorID, constants.InvestmentActive, borrowerID, currency).
+		Scan(&invested).Error; err != nil {
+		return decimal.Zero, err
+	}
+	if err := tx.Table("payouts").
+		Select("COALESCE(SUM(payouts.principal), 0)").
+		Joins("JOIN loans ON loans.id = payouts.loan_id").
+		Where("payouts.investor_id = ? AND loans.borrower_id = ? AND loans.currency = ?", investorID, borrowerID, currency).
+		Scan(&received).Error; err != nil {
+		return decimal.Zero, err
+	}
+	return invested.Sub(received), nil
+}
+
+func formatAmount(amount decimal.Decimal, currency money.Currency) string {
+	return money.Format(amount, currency) + " " + string(currency)
+}
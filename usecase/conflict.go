@@ -0,0 +1,32 @@
+package usecase
+
+import (
+	"errors"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// pgSerializationFailure is the postgres error code of a serializable transaction that conflicted with a concurrent one
+const pgSerializationFailure = "40001"
+
+// isSerializationFailure tells whether the database aborted a serializable transaction, it succeeds when run again
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgSerializationFailure
+}
+
+// retrySerializable runs attempt again when its serializable transaction was aborted by a concurrent one, a conflict
+// left after the last attempt is reported as ErrConcurrentUpdate
+func retrySerializable(attempt func() error) error {
+	for i := 1; ; i++ {
+		err := attempt()
+		if !isSerializationFailure(err) {
+			return err
+		}
+		if i == constants.MaxSerializableAttempts {
+			return errors.New(errs.ErrConcurrentUpdate)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/logger"
+	"loan-service/utils/storage"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// staffRoles can read every document, they review the documents of the loans they act on
+var staffRoles = []constants.UserRole{constants.RoleAdmin, constants.RoleValidator, constants.RoleSupervisor, constants.RoleDisburser}
+
+// uploadTypes are the file types accepted per kind of uploaded document, a signed agreement may be a scan or a photo
+var uploadTypes = map[constants.DocumentKind][]string{
+	constants.DocumentApprovalPhoto:   {"image/jpeg", "image/png"},
+	constants.DocumentSignedAgreement: {"application/pdf", "image/jpeg", "image/png"},
+}
+
+var uploadExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+type DocumentUsecase struct {
+	db        *gorm.DB
+	store     storage.Store
+	publicURL string
+}
+
+func NewDocumentUsecase(db *gorm.DB, store storage.Store, publicURL string) *DocumentUsecase {
+	return &DocumentUsecase{
+		db:        db,
+		store:     store,
+		publicURL: strings.TrimRight(publicURL, "/"),
+	}
+}
+
+// storedContentKey is the context key of the content stored by SaveDocument, see TrackContent
+type storedContentKey struct{}
+
+// TrackContent returns a context under which SaveDocument remembers the content it stores, release removes that
+// content again unless the transaction recording the documents committed
+func (u *DocumentUsecase) TrackContent(ctx context.Context) (context.Context, func(committed bool)) {
+	keys := &[]string{}
+	return context.WithValue(ctx, storedContentKey{}, keys), func(committed bool) {
+		if committed {
+			return
+		}
+		for _, key := range *keys {
+			u.removeContent(ctx, key)
+		}
+	}
+}
+
+// removeContent deletes a content no committed document refers to, documents with the same hash share their content
+func (u *DocumentUsecase) removeContent(ctx context.Context, key string) {
+	var documents int64
+	if err := u.db.Model(&entity.Document{}).Where("storage_key = ?", key).Count(&documents).Error; err != nil {
+		logger.Error("Failed to check references of document content", zap.String("key", key), zap.Error(err))
+		return
+	}
+	if documents > 0 {
+		return
+	}
+	if err := u.store.Delete(ctx, key); err != nil {
+		logger.Error("Failed to remove document content", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// SaveDocument stores the content and records the document with tx. The content is stored first so a recorded
+// document always has its content, the caller tracks the content with TrackContent to remove it on a rollback.
+func (u *DocumentUsecase) SaveDocument(ctx context.Context, tx *gorm.DB, document entity.Document, body []byte) (*entity.Document, error) {
+	hash := sha256.Sum256(body)
+	document.SHA256 = hex.EncodeToString(hash[:])
+	document.Size = int64(len(body))
+	document.StorageKey = fmt.Sprintf("documents/%s/%s", document.SHA256[:2], document.SHA256)
+
+	if err := u.store.Put(ctx, document.StorageKey, body, document.MimeType); err != nil {
+		logger.Error("Failed to store document", zap.String("name", document.Name), zap.Error(err))
+		return nil, err
+	}
+	if keys, ok := ctx.Value(storedContentKey{}).(*[]string); ok {
+		*keys = append(*keys, document.StorageKey)
+	}
+	if err := tx.Create(&document).Error; err != nil {
+		logger.Error("Failed to record document", zap.String("name", document.Name), zap.Error(err))
+		return nil, err
+	}
+	return &document, nil
+}
+
+// Link is where the document can be downloaded from
+func (u *DocumentUsecase) Link(document entity.Document) string {
+	return fmt.Sprintf("%s/api/documents/%d", u.publicURL, document.ID)
+}
+
+// UploadDocument stores a file uploaded for a loan. The type is sniffed from the content, the name and MIME type sent by
+// the client are not trusted. Images are re-encoded, which drops their metadata (ie. the location of a photo) and
+// anything appended to the image data.
+func (u *DocumentUsecase) UploadDocument(ctx context.Context, uploadRequest entity.RequestUploadDocument, body []byte, uploader entity.Actor) (*entity.Document, error) {
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(body))
+	if err != nil || !slices.Contains(uploadTypes[uploadRequest.Kind], mimeType) {
+		return nil, errors.New(errs.ErrUnsupportedDocument)
+	}
+	if mimeType != "application/pdf" {
+		if body, err = reencodeImage(body, mimeType); err != nil {
+			logger.Error("Failed to re-encode uploaded image", zap.Uint("loanID", uploadRequest.LoanID), zap.Error(err))
+			return nil, errors.New(errs.ErrUnsupportedDocument)
+		}
+	}
+
+	var loan entity.Loan
+	if err := u.db.First(&loan, "id = ?", uploadRequest.LoanID).Error; err != nil {
+		logger.Error("Failed to find loan of uploaded document", zap.Uint("loanID", uploadRequest.LoanID), zap.Error(err))
+		return nil, errors.New(errs.ErrLoanNotFound)
+	}
+
+	ctx, release := u.TrackContent(ctx)
+	document, err := u.SaveDocument(ctx, u.db, entity.Document{
+		Kind:     uploadRequest.Kind,
+		Name:     fmt.Sprintf("%s_%d%s", uploadRequest.Kind, loan.ID, uploadExtensions[mimeType]),
+		MimeType: mimeType,
+		OwnerID:  uploader.ID,
+		LoanID:   &loan.ID,
+	}, body)
+	release(err == nil)
+	return document, err
+}
+
+// reencodeImage decodes the image and encodes it again in the same format, the size is checked before decoding so a
+// small file can not expand into a huge image
+func reencodeImage(body []byte, mimeType string) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > constants.MaxUploadPixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if mimeType == "image/png" {
+		err = png.Encode(&out, img)
+	} else {
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: 90})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// attachedDocument finds a document of the kind uploaded for the loan, it is how the approval and disbursement
+// reference their uploads
+func attachedDocument(db *gorm.DB, documentID uint, kind constants.DocumentKind, loanID uint) (*entity.Document, error) {
+	var document entity.Document
+	if err := db.First(&document, "id = ? AND kind = ? AND loan_id = ?", documentID, kind, loanID).Error; err != nil {
+		logger.Error("Failed to find document attached to loan", zap.Uint("documentID", documentID), zap.Uint("loanID", loanID), zap.Error(err))
+		return nil, errors.New(errs.ErrDocumentNotAttached)
+	}
+	return &document, nil
+}
+
+// OpenDocument returns the document with its content once the actor is allowed to read it, the caller closes the content
+func (u *DocumentUsecase) OpenDocument(ctx context.Context, documentID uint, actor entity.Actor) (*entity.Document, io.ReadCloser, error) {
+	var document entity.Document
+	if err := u.db.First(&document, "id = ?", documentID).Error; err != nil {
+		logger.Error("Failed to find document", zap.Uint("documentID", documentID), zap.Error(err))
+		return nil, nil, errors.New(errs.ErrDocumentNotFound)
+	}
+
+	allowed, err := u.canRead(document, actor)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !allowed {
+		return nil, nil, errors.New(errs.ErrUnauthorizedAction)
+	}
+
+	content, err := u.store.Get(ctx, document.StorageKey)
+	if err != nil {
+		logger.Error("Failed to read document content", zap.Uint("documentID", documentID), zap.Error(err))
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, errors.New(errs.ErrDocumentNotFound)
+		}
+		return nil, nil, err
+	}
+	return &document, content, nil
+}
+
+// canRead lets staff read every document and users their own documents. Investors can also read the agreement of a
+// loan open for investment, as the marketplace links it before they invest, and of a loan they hold an active
+// investment in.
+func (u *DocumentUsecase) canRead(document entity.Document, actor entity.Actor) (bool, error) {
+	if slices.Contains(staffRoles, actor.Role) || document.OwnerID == actor.ID {
+		return true, nil
+	}
+	if actor.Role != constants.RoleInvestor || document.Kind != constants.DocumentLoanAgreement || document.LoanID == nil {
+		return false, nil
+	}
+
+	var loan entity.Loan
+	if err := u.db.Select("id", "status").First(&loan, "id = ?", *document.LoanID).Error; err != nil {
+		logger.Error("Failed to fetch loan of document", zap.Uint("documentID", document.ID), zap.Error(err))
+		return false, err
+	}
+	if loan.Status == constants.StatusApproved {
+		return true, nil
+	}
+
+	var investments int64
+	if err := u.db.Model(&entity.Investment{}).
+		Where("loan_id = ? AND investor_id = ? AND status = ?", *document.LoanID, actor.ID, constants.InvestmentActive).
+		Count(&investments).Error; err != nil {
+		logger.Error("Failed to check investor access to document", zap.Uint("documentID", document.ID), zap.Error(err))
+		return false, err
+	}
+	return investments > 0, nil
+}
@@ -0,0 +1,408 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"loan-service/entity"
+	"loan-service/usecase"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/storage"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentUsecase_SaveDocument(t *testing.T) {
+	body := []byte("%PDF-1.3 agreement")
+	hash := sha256.Sum256(body)
+	sum := hex.EncodeToString(hash[:])
+	key := "documents/" + sum[:2] + "/" + sum
+	loanID := uint(1)
+
+	db, mockSql := setupMockDB(t)
+	store, err := storage.NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+	u := usecase.NewDocumentUsecase(db, store, "http://localhost:8080/")
+
+	mockSql.ExpectBegin()
+	mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "documents"`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), constants.DocumentLoanAgreement, "loan_agreement_1.pdf", key,
+			"application/pdf", int64(len(body)), sum, uint(2), loanID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mockSql.ExpectCommit()
+
+	got, err := u.SaveDocument(context.Background(), db, entity.Document{
+		Kind:     constants.DocumentLoanAgreement,
+		Name:     "loan_agreement_1.pdf",
+		MimeType: "application/pdf",
+		OwnerID:  2,
+		LoanID:   &loanID,
+	}, body)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), got.ID)
+	assert.Equal(t, sum, got.SHA256)
+	assert.Equal(t, "http://localhost:8080/api/documents/7", u.Link(*got))
+
+	content, err := store.Get(context.Background(), key)
+	assert.NoError(t, err)
+	stored, _ := io.ReadAll(content)
+	content.Close()
+	assert.Equal(t, body, stored)
+	assert.NoError(t, mockSql.ExpectationsWereMet())
+}
+
+func TestDocumentUsecase_TrackContent(t *testing.T) {
+	body := []byte("%PDF-1.3 agreement")
+	hash := sha256.Sum256(body)
+	sum := hex.EncodeToString(hash[:])
+	key := "documents/" + sum[:2] + "/" + sum
+
+	tests := []struct {
+		name        string
+		committed   bool
+		mockFunc    func(mockSql sqlmock.Sqlmock)
+		wantRemoved bool
+	}{
+		{
+			name:      "Content of a committed document is kept",
+			committed: true,
+		},
+		{
+			name: "Content of a rolled back document is removed",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "documents" WHERE storage_key = $1`)).
+					WithArgs(key).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			},
+			wantRemoved: true,
+		},
+		{
+			name: "Content shared with a committed document is kept",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "documents" WHERE storage_key = $1`)).
+					WithArgs(key).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			store, err := storage.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			u := usecase.NewDocumentUsecase(db, store, "http://localhost:8080")
+
+			mockSql.ExpectBegin()
+			mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "documents"`)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			mockSql.ExpectCommit()
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+
+			ctx, release := u.TrackContent(context.Background())
+			_, err = u.SaveDocument(ctx, db, entity.Document{Kind: constants.DocumentLoanAgreement, MimeType: "application/pdf"}, body)
+			assert.NoError(t, err)
+			release(tt.committed)
+
+			content, err := store.Get(context.Background(), key)
+			if tt.wantRemoved {
+				assert.ErrorIs(t, err, storage.ErrNotFound)
+			} else {
+				assert.NoError(t, err)
+				content.Close()
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDocumentUsecase_UploadDocument(t *testing.T) {
+	loanID := uint(1)
+	uploader := entity.Actor{ID: 2, Role: constants.RoleValidator}
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+	var jpegBody, pngBody bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&jpegBody, img, nil))
+	assert.NoError(t, png.Encode(&pngBody, img))
+	// data appended after the image, ie. a hidden payload, is dropped by the re-encoding
+	trailer := []byte("appended payload")
+	pdfBody := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n%%EOF\n")
+
+	expectLoan := func(mockSql sqlmock.Sqlmock) {
+		mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans" WHERE id = $1 ORDER BY "loans"."id" LIMIT $2`)).
+			WithArgs(loanID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(loanID))
+	}
+	expectInsert := func(mockSql sqlmock.Sqlmock, kind constants.DocumentKind, name, mimeType string) {
+		mockSql.ExpectBegin()
+		mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "documents"`)).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), kind, name, sqlmock.AnyArg(), mimeType, sqlmock.AnyArg(), sqlmock.AnyArg(), uploader.ID, loanID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+		mockSql.ExpectCommit()
+	}
+
+	tests := []struct {
+		name         string
+		kind         constants.DocumentKind
+		body         []byte
+		mockFunc     func(mockSql sqlmock.Sqlmock)
+		wantMimeType string
+		wantErr      error
+	}{
+		{
+			name: "JPEG approval photo is re-encoded",
+			kind: constants.DocumentApprovalPhoto,
+			body: append(append([]byte{}, jpegBody.Bytes()...), trailer...),
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectLoan(mockSql)
+				expectInsert(mockSql, constants.DocumentApprovalPhoto, "approval_photo_1.jpg", "image/jpeg")
+			},
+			wantMimeType: "image/jpeg",
+		},
+		{
+			name: "PNG signed agreement",
+			kind: constants.DocumentSignedAgreement,
+			body: pngBody.Bytes(),
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectLoan(mockSql)
+				expectInsert(mockSql, constants.DocumentSignedAgreement, "signed_agreement_1.png", "image/png")
+			},
+			wantMimeType: "image/png",
+		},
+		{
+			name: "PDF signed agreement is stored as is",
+			kind: constants.DocumentSignedAgreement,
+			body: pdfBody,
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectLoan(mockSql)
+				expectInsert(mockSql, constants.DocumentSignedAgreement, "signed_agreement_1.pdf", "application/pdf")
+			},
+			wantMimeType: "application/pdf",
+		},
+		{
+			name:    "PDF is not an approval photo",
+			kind:    constants.DocumentApprovalPhoto,
+			body:    pdfBody,
+			wantErr: errors.New(errs.ErrUnsupportedDocument),
+		},
+		{
+			name:    "Unsupported file type",
+			kind:    constants.DocumentSignedAgreement,
+			body:    []byte("<html><body>agreement</body></html>"),
+			wantErr: errors.New(errs.ErrUnsupportedDocument),
+		},
+		{
+			name:    "Corrupt image",
+			kind:    constants.DocumentApprovalPhoto,
+			body:    append([]byte{0xff, 0xd8, 0xff, 0xe0}, []byte("not a jpeg")...),
+			wantErr: errors.New(errs.ErrUnsupportedDocument),
+		},
+		{
+			name: "Loan not found",
+			kind: constants.DocumentApprovalPhoto,
+			body: pngBody.Bytes(),
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans" WHERE id = $1`)).
+					WithArgs(loanID, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: errors.New(errs.ErrLoanNotFound),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			store, err := storage.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			u := usecase.NewDocumentUsecase(db, store, "http://localhost:8080")
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+
+			got, err := u.UploadDocument(context.Background(), entity.RequestUploadDocument{LoanID: loanID, Kind: tt.kind}, tt.body, uploader)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(5), got.ID)
+				assert.Equal(t, tt.wantMimeType, got.MimeType)
+
+				content, err := store.Get(context.Background(), got.StorageKey)
+				assert.NoError(t, err)
+				stored, _ := io.ReadAll(content)
+				content.Close()
+				assert.Equal(t, got.Size, int64(len(stored)))
+				assert.False(t, bytes.Contains(stored, trailer))
+				if tt.wantMimeType == "application/pdf" {
+					assert.Equal(t, tt.body, stored)
+				} else {
+					_, format, err := image.Decode(bytes.NewReader(stored))
+					assert.NoError(t, err)
+					assert.Equal(t, tt.wantMimeType, "image/"+format)
+				}
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDocumentUsecase_OpenDocument(t *testing.T) {
+	documentID := uint(7)
+	loanID := uint(1)
+	ownerID := uint(2)
+	investorID := uint(3)
+	body := []byte("%PDF-1.3 agreement")
+	key := "documents/ab/abcdef"
+
+	expectDocument := func(mockSql sqlmock.Sqlmock, kind constants.DocumentKind) {
+		mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "documents" WHERE id = $1 ORDER BY "documents"."id" LIMIT $2`)).
+			WithArgs(documentID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "name", "storage_key", "mime_type", "size", "owner_id", "loan_id"}).
+				AddRow(documentID, kind, "agreement.pdf", key, "application/pdf", len(body), ownerID, loanID))
+	}
+	expectLoanStatus := func(mockSql sqlmock.Sqlmock, status constants.LoanStatus) {
+		mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT "id","status" FROM "loans" WHERE id = $1 ORDER BY "loans"."id" LIMIT $2`)).
+			WithArgs(loanID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(loanID, status))
+	}
+	expectInvestments := func(mockSql sqlmock.Sqlmock, count int) {
+		mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "investments" WHERE loan_id = $1 AND investor_id = $2 AND status = $3`)).
+			WithArgs(loanID, investorID, constants.InvestmentActive).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+	}
+
+	tests := []struct {
+		name     string
+		actor    entity.Actor
+		stored   bool
+		mockFunc func(mockSql sqlmock.Sqlmock)
+		wantErr  error
+	}{
+		{
+			name:   "Owner reads the document",
+			actor:  entity.Actor{ID: ownerID, Role: constants.RoleBorrower},
+			stored: true,
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectDocument(mockSql, constants.DocumentLoanAgreement)
+			},
+		},
+		{
+			name:   "Staff reads the document",
+			actor:  entity.Actor{ID: 9, Role: constants.RoleValidator},
+			stored: true,
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectDocument(mockSql, constants.DocumentLoanAgreement)
+			},
+		},
+		{
+			name:   "Investor of the loan reads the loan agreement",
+			actor:  entity.Actor{ID: investorID, Role: constants.RoleInvestor},
+			stored: true,
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectDocument(mockSql, constants.DocumentLoanAgreement)
+				expectLoanStatus(mockSql, constants.StatusInvested)
+				expectInvestments(mockSql, 1)
+			},
+		},
+		{
+			name:   "Investor reads the agreement of a loan open for investment",
+			actor:  entity.Actor{ID: investorID, Role: constants.RoleInvestor},
+			stored: true,
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectDocument(mockSql, constants.DocumentLoanAgreement)
+				expectLoanStatus(mockSql, constants.StatusApproved)
+			},
+		},
+		{
+			name:   "Investor without investment in the loan",
+			actor:  entity.Actor{ID: investorID, Role: constants.RoleInvestor},
+			stored: true,
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectDocument(mockSql, constants.DocumentLoanAgreement)
+				expectLoanStatus(mockSql, constants.StatusInvested)
+				expectInvestments(mockSql, 0)
+			},
+			wantErr: errors.New(errs.ErrUnauthorizedAction),
+		},
+		{
+			name:   "Investor reads the agreement of a loan still being reviewed",
+			actor:  entity.Actor{ID: investorID, Role: constants.RoleInvestor},
+			stored: true,
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectDocument(mockSql, constants.DocumentLoanAgreement)
+				expectLoanStatus(mockSql, constants.StatusProposed)
+				expectInvestments(mockSql, 0)
+			},
+			wantErr: errors.New(errs.ErrUnauthorizedAction),
+		},
+		{
+			name:   "Investor reads the agreement of another investor",
+			actor:  entity.Actor{ID: investorID, Role: constants.RoleInvestor},
+			stored: true,
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectDocument(mockSql, constants.DocumentInvestmentAgreement)
+			},
+			wantErr: errors.New(errs.ErrUnauthorizedAction),
+		},
+		{
+			name:  "Document not found",
+			actor: entity.Actor{ID: ownerID, Role: constants.RoleBorrower},
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "documents" WHERE id = $1`)).
+					WithArgs(documentID, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: errors.New(errs.ErrDocumentNotFound),
+		},
+		{
+			name:  "Content missing from the store",
+			actor: entity.Actor{ID: ownerID, Role: constants.RoleBorrower},
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectDocument(mockSql, constants.DocumentLoanAgreement)
+			},
+			wantErr: errors.New(errs.ErrDocumentNotFound),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			store, err := storage.NewFileStore(t.TempDir())
+			assert.NoError(t, err)
+			if tt.stored {
+				assert.NoError(t, store.Put(context.Background(), key, body, "application/pdf"))
+			}
+			u := usecase.NewDocumentUsecase(db, store, "http://localhost:8080")
+			tt.mockFunc(mockSql)
+
+			document, content, err := u.OpenDocument(context.Background(), documentID, tt.actor)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, document)
+				assert.Nil(t, content)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, documentID, document.ID)
+				got, _ := io.ReadAll(content)
+				content.Close()
+				assert.Equal(t, body, got)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"loan-service/entity"
+	"loan-service/statemachine"
+	"loan-service/utils/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ExpireLoans moves the approved loans that were not fully invested within the funding window to expired,
+// their investments are released and the investors notified. A loan that fails to expire is retried on the next run.
+func (u *LoanUsecase) ExpireLoans(ctx context.Context, fundingWindow time.Duration) (int, error) {
+	deadline := time.Now().Add(-fundingWindow)
+
+	var loans []entity.Loan
+	if err := u.db.Joins("JOIN loan_approvals ON loan_approvals.loan_id = loans.id").
+		Where("loans.status IN ? AND loan_approvals.approved_at < ?", u.machine.From(statemachine.EventExpire), deadline).
+		Order("loans.id").
+		Find(&loans).Error; err != nil {
+		logger.Error("Failed to fetch loans past their funding deadline", zap.Error(err))
+		return 0, err
+	}
+
+	expired := 0
+	for _, loan := range loans {
+		if err := u.expireLoan(ctx, loan.ID, fundingWindow); err != nil {
+			logger.Error("Failed to expire loan", zap.Uint("loanID", loan.ID), zap.Error(err))
+			continue
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// RunExpiryWorker calls ExpireLoans on every tick until the context is cancelled
+func (u *LoanUsecase) RunExpiryWorker(ctx context.Context, interval, fundingWindow time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			u.ExpireLoans(ctx, fundingWindow)
+		}
+	}
+}
+
+// expireLoan expires a single approved loan whose funding window has passed and voids its investments
+func (u *LoanUsecase) expireLoan(ctx context.Context, loanID uint, fundingWindow time.Duration) error {
+	unlock, err := u.lockLoan(ctx, loanID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tx := u.db.Begin(&sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	defer tx.Rollback()
+
+	var loan entity.Loan
+	if err := tx.First(&loan, "id = ? AND status IN ?", loanID, u.machine.From(statemachine.EventExpire)).Error; err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{"funding_window": fundingWindow.String()}
+	if err := u.machine.Fire(ctx, tx, &loan, statemachine.EventExpire, entity.SystemActor, payload); err != nil {
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	logger.Info("Loan expired", zap.Uint("loanID", loan.ID))
+	u.notifier.Wake()
+	return nil
+}
+
+// queueExpiredNotifications runs after voidInvestments so the investors of the released investments are notified
+func (u *LoanUsecase) queueExpiredNotifications(ctx context.Context, change statemachine.Change) error {
+	return u.notifier.QueueLoanExpired(ctx, change.Tx, change.Loan.ID)
+}
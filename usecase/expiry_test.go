@@ -0,0 +1,111 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"loan-service/entity"
+	"loan-service/statemachine"
+	"loan-service/usecase"
+	"loan-service/utils/constants"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoanUsecase_ExpireLoans(t *testing.T) {
+	fundingWindow := 14 * 24 * time.Hour
+	tests := []struct {
+		name        string
+		mockFunc    func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock)
+		want        int
+		wantExpired []uint
+		wantErr     error
+	}{
+		{
+			name: "ExpireLoans_Success",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`FROM "loans" JOIN loan_approvals ON loan_approvals.loan_id = loans.id `+
+						`WHERE loans.status IN ($1) AND loan_approvals.approved_at < $2 ORDER BY loans.id`)).
+					WithArgs(constants.StatusApproved, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).
+						AddRow(1, constants.StatusApproved).
+						AddRow(2, constants.StatusApproved))
+
+				mockRedis.ExpectSetNX("event_lock:1", "locked", 5*time.Second).SetVal(true)
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans" WHERE id = $1 AND status IN ($2)`)).
+					WithArgs(1, constants.StatusApproved, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "borrower_id", "principal", "status"}).
+						AddRow(1, 2, 1000, constants.StatusApproved))
+				mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "loans"`)).
+					WithArgs(
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusExpired, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1,
+					).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "investments" SET "status"=$1,"updated_at"=$2 WHERE loan_id = $3 AND status = $4`)).
+					WithArgs(constants.InvestmentVoided, sqlmock.AnyArg(), 1, constants.InvestmentActive).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				expectLoanEvent(mockSql, 1, statemachine.EventExpire, constants.StatusApproved, constants.StatusExpired, entity.SystemActor)
+				mockSql.ExpectCommit()
+				mockRedis.ExpectDel("event_lock:1").SetVal(1)
+
+				// the second loan is being invested in, it is picked up on the next run
+				mockRedis.ExpectSetNX("event_lock:2", "locked", 5*time.Second).SetVal(false)
+			},
+			want:        1,
+			wantExpired: []uint{1},
+		},
+		{
+			name: "ExpireLoans_Success_AlreadyInvested",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`FROM "loans" JOIN loan_approvals`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, constants.StatusApproved))
+
+				mockRedis.ExpectSetNX("event_lock:1", "locked", 5*time.Second).SetVal(true)
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans" WHERE id = $1 AND status IN ($2)`)).
+					WithArgs(1, constants.StatusApproved, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mockSql.ExpectRollback()
+				mockRedis.ExpectDel("event_lock:1").SetVal(1)
+			},
+			want: 0,
+		},
+		{
+			name: "ExpireLoans_Failure_Query",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`FROM "loans" JOIN loan_approvals`)).
+					WillReturnError(fmt.Errorf("DB error"))
+			},
+			wantErr: fmt.Errorf("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			redis, mockRedis := redismock.NewClientMock()
+			notifier := &fakeNotifier{}
+			u := usecase.NewLoanUsecase(db, redis, notifier, &fakeDocuments{}, usecase.LoanPolicy{})
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql, mockRedis)
+			}
+
+			got, err := u.ExpireLoans(context.Background(), fundingWindow)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantExpired, notifier.expired)
+			assert.Equal(t, len(tt.wantExpired), notifier.woken)
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+			assert.NoError(t, mockRedis.ExpectationsWereMet())
+		})
+	}
+}
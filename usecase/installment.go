@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"errors"
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/money"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// GenerateInstallments builds the repayment schedule of a loan starting from the given date.
+// Rate defines the total interest over the whole tenor, so principal and interest are spread
+// evenly over every installment and rounding leftovers are settled on the last one.
+func GenerateInstallments(loan entity.Loan, start time.Time) ([]entity.Installment, error) {
+	if loan.Tenor <= 0 || !loan.Principal.IsPositive() {
+		return nil, errors.New(errs.ErrInvalidLoanTerms)
+	}
+
+	tenor := decimal.NewFromInt(int64(loan.Tenor))
+	totalInterest := money.Round(loan.Principal.Mul(loan.Rate).Div(hundred), loan.Currency)
+	principalPart := money.Round(loan.Principal.Div(tenor), loan.Currency)
+	interestPart := money.Round(totalInterest.Div(tenor), loan.Currency)
+
+	remainingPrincipal := loan.Principal
+	remainingInterest := totalInterest
+	installments := make([]entity.Installment, 0, loan.Tenor)
+	for seq := 1; seq <= loan.Tenor; seq++ {
+		dueDate, err := installmentDueDate(start, loan.Frequency, seq)
+		if err != nil {
+			return nil, err
+		}
+
+		principal, interest := principalPart, interestPart
+		if seq == loan.Tenor {
+			principal, interest = remainingPrincipal, remainingInterest
+		}
+		remainingPrincipal = remainingPrincipal.Sub(principal)
+		remainingInterest = remainingInterest.Sub(interest)
+
+		installments = append(installments, entity.Installment{
+			LoanID:             loan.ID,
+			Sequence:           seq,
+			DueDate:            dueDate,
+			Principal:          principal,
+			Interest:           interest,
+			Amount:             principal.Add(interest),
+			OutstandingBalance: remainingPrincipal,
+			Status:             constants.InstallmentPending,
+		})
+	}
+
+	return installments, nil
+}
+
+func installmentDueDate(start time.Time, frequency constants.RepaymentFrequency, seq int) (time.Time, error) {
+	switch frequency {
+	case constants.FrequencyWeekly:
+		return start.AddDate(0, 0, 7*seq), nil
+	case constants.FrequencyMonthly:
+		return start.AddDate(0, seq, 0), nil
+	default:
+		return time.Time{}, errors.New(errs.ErrInvalidLoanTerms)
+	}
+}
+
+var hundred = decimal.NewFromInt(100)
+
+// percentFunded returns the funded share of a principal as a percentage with two decimal places
+func percentFunded(funded, principal decimal.Decimal) decimal.Decimal {
+	if !principal.IsPositive() {
+		return decimal.Zero
+	}
+	return funded.Mul(hundred).Div(principal).Round(2)
+}
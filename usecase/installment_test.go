@@ -0,0 +1,103 @@
+package usecase_test
+
+import (
+	"fmt"
+	"loan-service/entity"
+	"loan-service/usecase"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateInstallments(t *testing.T) {
+	start := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		loan          entity.Loan
+		wantDueDates  []time.Time
+		wantPrincipal []float64
+		wantInterest  []float64
+		wantBalance   []float64
+		wantErr       error
+	}{
+		{
+			name: "monthly schedule with even split",
+			loan: entity.Loan{
+				DBCommon:  entity.DBCommon{ID: 1},
+				Principal: decimal.NewFromInt(1200),
+				Rate:      decimal.NewFromInt(10),
+				Tenor:     3,
+				Frequency: constants.FrequencyMonthly,
+			},
+			wantDueDates: []time.Time{
+				start.AddDate(0, 1, 0),
+				start.AddDate(0, 2, 0),
+				start.AddDate(0, 3, 0),
+			},
+			wantPrincipal: []float64{400, 400, 400},
+			wantInterest:  []float64{40, 40, 40},
+			wantBalance:   []float64{800, 400, 0},
+		},
+		{
+			name: "weekly schedule settles rounding on the last installment",
+			loan: entity.Loan{
+				DBCommon:  entity.DBCommon{ID: 1},
+				Principal: decimal.NewFromInt(1000),
+				Rate:      decimal.NewFromInt(5),
+				Tenor:     3,
+				Frequency: constants.FrequencyWeekly,
+			},
+			wantDueDates: []time.Time{
+				start.AddDate(0, 0, 7),
+				start.AddDate(0, 0, 14),
+				start.AddDate(0, 0, 21),
+			},
+			wantPrincipal: []float64{333.33, 333.33, 333.34},
+			wantInterest:  []float64{16.67, 16.67, 16.66},
+			wantBalance:   []float64{666.67, 333.34, 0},
+		},
+		{
+			name: "zero tenor",
+			loan: entity.Loan{
+				Principal: decimal.NewFromInt(1000),
+				Rate:      decimal.NewFromInt(5),
+				Frequency: constants.FrequencyWeekly,
+			},
+			wantErr: fmt.Errorf(errs.ErrInvalidLoanTerms),
+		},
+		{
+			name: "unknown frequency",
+			loan: entity.Loan{
+				Principal: decimal.NewFromInt(1000),
+				Rate:      decimal.NewFromInt(5),
+				Tenor:     3,
+				Frequency: "daily",
+			},
+			wantErr: fmt.Errorf(errs.ErrInvalidLoanTerms),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := usecase.GenerateInstallments(tt.loan, start)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, got, tt.loan.Tenor)
+			for i, inst := range got {
+				assert.Equal(t, tt.loan.ID, inst.LoanID)
+				assert.Equal(t, i+1, inst.Sequence)
+				assert.Equal(t, tt.wantDueDates[i], inst.DueDate)
+				assertAmount(t, tt.wantPrincipal[i], inst.Principal)
+				assertAmount(t, tt.wantInterest[i], inst.Interest)
+				assertAmount(t, tt.wantBalance[i], inst.OutstandingBalance)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"errors"
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/logger"
+	"loan-service/utils/money"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type InvestorUsecase struct {
+	db *gorm.DB
+}
+
+func NewInvestorUsecase(db *gorm.DB) *InvestorUsecase {
+	return &InvestorUsecase{db: db}
+}
+
+func (u *InvestorUsecase) GetPayouts(investorID uint, loanID uint) ([]entity.Payout, error) {
+	query := u.db.Where("investor_id = ?", investorID)
+	if loanID != 0 {
+		query = query.Where("loan_id = ?", loanID)
+	}
+
+	payouts := []entity.Payout{}
+	if err := query.Order("id DESC").Find(&payouts).Error; err != nil {
+		logger.Error("Failed to fetch investor payouts", zap.Uint("investorID", investorID), zap.Error(err))
+		return nil, err
+	}
+	return payouts, nil
+}
+
+// GetMarketplace returns a page of approved loans with their funding progress. The investments are aggregated
+// in the same query instead of being preloaded for every loan.
+func (u *InvestorUsecase) GetMarketplace(marketplaceRequest entity.RequestMarketplace) ([]entity.MarketplaceLoan, string, error) {
+	page, err := newLoanPage(marketplaceRequest.SortBy, marketplaceRequest.Order, marketplaceRequest.Limit, marketplaceRequest.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if !validPrincipalRange(marketplaceRequest.MinPrincipal, marketplaceRequest.MaxPrincipal) {
+		return nil, "", errors.New(errs.ErrInvalidLoanFilter)
+	}
+
+	query := u.db.Table("loans").
+		Select("loans.id, loans.created_at, loans.borrower_id, loans.principal, loans.currency, loans.roi, loans.tenor, loans.frequency, "+
+			"loans.status, loans.agreement_link, loan_approvals.risk_grade, COALESCE(SUM(investments.amount), 0) AS funded_amount, "+
+			"COUNT(DISTINCT investments.investor_id) AS investor_count").
+		Joins("LEFT JOIN loan_approvals ON loan_approvals.loan_id = loans.id AND loan_approvals.reject_reason IS NULL").
+		Joins("LEFT JOIN investments ON investments.loan_id = loans.id AND investments.status = ?", constants.InvestmentActive).
+		Where("loans.status = ?", constants.StatusApproved)
+	if marketplaceRequest.Currency != "" {
+		query = query.Where("loans.currency = ?", marketplaceRequest.Currency)
+	}
+	if marketplaceRequest.MinPrincipal != nil {
+		query = query.Where("loans.principal >= ?", *marketplaceRequest.MinPrincipal)
+	}
+	if marketplaceRequest.MaxPrincipal != nil {
+		query = query.Where("loans.principal <= ?", *marketplaceRequest.MaxPrincipal)
+	}
+
+	loans := make([]entity.MarketplaceLoan, 0, page.limit+1)
+	if err := page.apply(query.Group("loans.id, loan_approvals.risk_grade"), "loans").Scan(&loans).Error; err != nil {
+		logger.Error("Failed to fetch marketplace loans", zap.Error(err))
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(loans) > page.limit {
+		last := loans[page.limit-1]
+		nextCursor = page.nextCursor(len(loans), last.ID, last.CreatedAt, last.Principal)
+		loans = loans[:page.limit]
+	}
+
+	for i := range loans {
+		loan := &loans[i]
+		loan.RemainingAmount = loan.Principal.Sub(loan.FundedAmount)
+		loan.PercentFunded = percentFunded(loan.FundedAmount, loan.Principal)
+		loan.ExpectedReturn = money.Round(loan.RemainingAmount.Mul(loan.ROI).Div(hundred), loan.Currency)
+	}
+
+	return loans, nextCursor, nil
+}
+
+// GetPortfolio aggregates the investments and payouts of an investor per loan. Outstanding exposure is the
+// invested principal that has not been paid back yet.
+func (u *InvestorUsecase) GetPortfolio(investorID uint) (*entity.Portfolio, error) {
+	loans := []entity.PortfolioLoan{}
+	if err := u.db.Table("investments").
+		Select("loans.id AS loan_id, loans.status, loans.currency, loans.roi, SUM(investments.amount) AS amount_invested").
+		Joins("JOIN loans ON loans.id = investments.loan_id").
+		Where("investments.investor_id = ? AND investments.status = ?", investorID, constants.InvestmentActive).
+		Group("loans.id").
+		Order("loans.id").
+		Scan(&loans).Error; err != nil {
+		logger.Error("Failed to fetch investor investments", zap.Uint("investorID", investorID), zap.Error(err))
+		return nil, err
+	}
+
+	var received []struct {
+		LoanID            uint
+		PrincipalReceived decimal.Decimal
+		ReturnReceived    decimal.Decimal
+		PayoutsReceived   decimal.Decimal
+	}
+	if err := u.db.Table("payouts").
+		Select(`loan_id, SUM(principal) AS principal_received, SUM("return") AS return_received, SUM(amount) AS payouts_received`).
+		Where("investor_id = ?", investorID).
+		Group("loan_id").
+		Scan(&received).Error; err != nil {
+		logger.Error("Failed to fetch investor payouts", zap.Uint("investorID", investorID), zap.Error(err))
+		return nil, err
+	}
+
+	portfolio := &entity.Portfolio{Loans: loans, Totals: []entity.PortfolioTotal{}}
+	totalIndex := map[money.Currency]int{}
+	for i := range portfolio.Loans {
+		loan := &portfolio.Loans[i]
+		for _, r := range received {
+			if r.LoanID == loan.LoanID {
+				loan.PrincipalReceived = r.PrincipalReceived
+				loan.ReturnReceived = r.ReturnReceived
+				loan.PayoutsReceived = r.PayoutsReceived
+			}
+		}
+		loan.ExpectedReturn = money.Round(loan.AmountInvested.Mul(loan.ROI).Div(hundred), loan.Currency)
+		loan.OutstandingExposure = loan.AmountInvested.Sub(loan.PrincipalReceived)
+
+		idx, ok := totalIndex[loan.Currency]
+		if !ok {
+			idx = len(portfolio.Totals)
+			totalIndex[loan.Currency] = idx
+			portfolio.Totals = append(portfolio.Totals, entity.PortfolioTotal{Currency: loan.Currency})
+		}
+		total := &portfolio.Totals[idx]
+		total.TotalInvested = total.TotalInvested.Add(loan.AmountInvested)
+		total.TotalExpectedReturn = total.TotalExpectedReturn.Add(loan.ExpectedReturn)
+		total.TotalPayoutsReceived = total.TotalPayoutsReceived.Add(loan.PayoutsReceived)
+		total.TotalOutstandingExposure = total.TotalOutstandingExposure.Add(loan.OutstandingExposure)
+	}
+
+	return portfolio, nil
+}
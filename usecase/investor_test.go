@@ -0,0 +1,300 @@
+package usecase_test
+
+import (
+	"fmt"
+	"loan-service/entity"
+	"loan-service/usecase"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/money"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvestorUsecase_GetPayouts(t *testing.T) {
+	investorID := uint(3)
+	tests := []struct {
+		name     string
+		loanID   uint
+		mockFunc func(mockSql sqlmock.Sqlmock)
+		wantLen  int
+		wantErr  error
+	}{
+		{
+			name: "GetPayouts_Success",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "payouts" WHERE investor_id = $1 ORDER BY id DESC`)).
+					WithArgs(investorID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "investor_id", "principal", "return", "amount"}).
+						AddRow(2, 1, investorID, 100, 9, 109).
+						AddRow(1, 2, investorID, 50, 4, 54))
+			},
+			wantLen: 2,
+		},
+		{
+			name:   "GetPayouts_Success_FilterByLoan",
+			loanID: 1,
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "payouts" WHERE investor_id = $1 AND loan_id = $2 ORDER BY id DESC`)).
+					WithArgs(investorID, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "investor_id", "principal", "return", "amount"}).
+						AddRow(2, 1, investorID, 100, 9, 109))
+			},
+			wantLen: 1,
+		},
+		{
+			name: "GetPayouts_Failure_DBError",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "payouts"`)).
+					WillReturnError(fmt.Errorf("DB error"))
+			},
+			wantErr: fmt.Errorf("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			u := usecase.NewInvestorUsecase(db)
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+			got, err := u.GetPayouts(investorID, tt.loanID)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.wantLen)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInvestorUsecase_GetMarketplace(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        entity.RequestMarketplace
+		mockFunc       func(mockSql sqlmock.Sqlmock)
+		want           []entity.MarketplaceLoan
+		wantNextCursor bool
+		wantErr        error
+	}{
+		{
+			name:    "GetMarketplace_Success",
+			request: entity.RequestMarketplace{Limit: 1},
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT loans.id, loans.created_at, loans.borrower_id, loans.principal, loans.currency, loans.roi, loans.tenor, loans.frequency, `+
+						`loans.status, loans.agreement_link, loan_approvals.risk_grade, COALESCE(SUM(investments.amount), 0) AS funded_amount, `+
+						`COUNT(DISTINCT investments.investor_id) AS investor_count FROM "loans" `+
+						`LEFT JOIN loan_approvals ON loan_approvals.loan_id = loans.id AND loan_approvals.reject_reason IS NULL `+
+						`LEFT JOIN investments ON investments.loan_id = loans.id AND investments.status = $1 WHERE loans.status = $2 GROUP BY loans.id, loan_approvals.risk_grade `+
+						`ORDER BY loans.created_at desc, loans.id desc LIMIT $3`)).
+					WithArgs(constants.InvestmentActive, constants.StatusApproved, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "roi", "status", "risk_grade", "funded_amount", "investor_count"}).
+						AddRow(5, 1000, 8, constants.StatusApproved, constants.RiskGradeB, 400, 2).
+						AddRow(4, 500, 6, constants.StatusApproved, constants.RiskGradeA, 0, 0))
+			},
+			want: []entity.MarketplaceLoan{
+				{
+					ID:              5,
+					Principal:       decimal.NewFromInt(1000),
+					ROI:             decimal.NewFromInt(8),
+					Status:          constants.StatusApproved,
+					RiskGrade:       constants.RiskGradeB,
+					FundedAmount:    decimal.NewFromInt(400),
+					RemainingAmount: decimal.NewFromInt(600),
+					PercentFunded:   decimal.NewFromInt(40),
+					InvestorCount:   2,
+					ExpectedReturn:  decimal.NewFromInt(48),
+				},
+			},
+			wantNextCursor: true,
+		},
+		{
+			name: "GetMarketplace_Success_PrincipalRange",
+			request: entity.RequestMarketplace{
+				MinPrincipal: func() *decimal.Decimal { v := decimal.RequireFromString("100"); return &v }(),
+				SortBy:       "principal",
+				Order:        "asc",
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`WHERE loans.status = $2 AND loans.principal >= $3 GROUP BY loans.id, loan_approvals.risk_grade ORDER BY loans.principal asc, loans.id asc LIMIT $4`)).
+					WithArgs(constants.InvestmentActive, constants.StatusApproved, decimal.NewFromInt(100), constants.DefaultLoanPageSize+1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "roi", "status", "funded_amount", "investor_count"}).
+						AddRow(4, 300, 6, constants.StatusApproved, 100, 1))
+			},
+			want: []entity.MarketplaceLoan{
+				{
+					ID:              4,
+					Principal:       decimal.NewFromInt(300),
+					ROI:             decimal.NewFromInt(6),
+					Status:          constants.StatusApproved,
+					FundedAmount:    decimal.NewFromInt(100),
+					RemainingAmount: decimal.NewFromInt(200),
+					PercentFunded:   decimal.RequireFromString("33.33"),
+					InvestorCount:   1,
+					ExpectedReturn:  decimal.NewFromInt(12),
+				},
+			},
+		},
+		{
+			name:    "GetMarketplace_Failure_InvalidCursor",
+			request: entity.RequestMarketplace{Cursor: "bad"},
+			wantErr: fmt.Errorf(errs.ErrInvalidCursor),
+		},
+		{
+			name: "GetMarketplace_Failure_DBError",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`FROM "loans"`)).
+					WillReturnError(fmt.Errorf("DB error"))
+			},
+			wantErr: fmt.Errorf("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			u := usecase.NewInvestorUsecase(db)
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+			got, nextCursor, err := u.GetMarketplace(tt.request)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assertSameJSON(t, tt.want, got)
+				assert.Equal(t, tt.wantNextCursor, nextCursor != "")
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInvestorUsecase_GetPortfolio(t *testing.T) {
+	investorID := uint(3)
+	tests := []struct {
+		name     string
+		mockFunc func(mockSql sqlmock.Sqlmock)
+		want     *entity.Portfolio
+		wantErr  error
+	}{
+		{
+			name: "GetPortfolio_Success",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT loans.id AS loan_id, loans.status, loans.currency, loans.roi, SUM(investments.amount) AS amount_invested FROM "investments" `+
+						`JOIN loans ON loans.id = investments.loan_id WHERE investments.investor_id = $1 AND investments.status = $2 GROUP BY "loans"."id" ORDER BY loans.id`)).
+					WithArgs(investorID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"loan_id", "status", "currency", "roi", "amount_invested"}).
+						AddRow(1, constants.StatusRepaying, money.IDR, 8, 600).
+						AddRow(2, constants.StatusApproved, money.SGD, 6, 200).
+						AddRow(3, constants.StatusApproved, money.IDR, 5, 100))
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT loan_id, SUM(principal) AS principal_received, SUM("return") AS return_received, SUM(amount) AS payouts_received ` +
+						`FROM "payouts" WHERE investor_id = $1 GROUP BY "loan_id"`)).
+					WithArgs(investorID).
+					WillReturnRows(sqlmock.NewRows([]string{"loan_id", "principal_received", "return_received", "payouts_received"}).
+						AddRow(1, 100, 8, 108))
+			},
+			want: &entity.Portfolio{
+				Loans: []entity.PortfolioLoan{
+					{
+						LoanID:              1,
+						Status:              constants.StatusRepaying,
+						Currency:            money.IDR,
+						ROI:                 decimal.NewFromInt(8),
+						AmountInvested:      decimal.NewFromInt(600),
+						ExpectedReturn:      decimal.NewFromInt(48),
+						PrincipalReceived:   decimal.NewFromInt(100),
+						ReturnReceived:      decimal.NewFromInt(8),
+						PayoutsReceived:     decimal.NewFromInt(108),
+						OutstandingExposure: decimal.NewFromInt(500),
+					},
+					{
+						LoanID:              2,
+						Status:              constants.StatusApproved,
+						Currency:            money.SGD,
+						ROI:                 decimal.NewFromInt(6),
+						AmountInvested:      decimal.NewFromInt(200),
+						ExpectedReturn:      decimal.NewFromInt(12),
+						OutstandingExposure: decimal.NewFromInt(200),
+					},
+					{
+						LoanID:              3,
+						Status:              constants.StatusApproved,
+						Currency:            money.IDR,
+						ROI:                 decimal.NewFromInt(5),
+						AmountInvested:      decimal.NewFromInt(100),
+						ExpectedReturn:      decimal.NewFromInt(5),
+						OutstandingExposure: decimal.NewFromInt(100),
+					},
+				},
+				Totals: []entity.PortfolioTotal{
+					{
+						Currency:                 money.IDR,
+						TotalInvested:            decimal.NewFromInt(700),
+						TotalExpectedReturn:      decimal.NewFromInt(53),
+						TotalPayoutsReceived:     decimal.NewFromInt(108),
+						TotalOutstandingExposure: decimal.NewFromInt(600),
+					},
+					{
+						Currency:                 money.SGD,
+						TotalInvested:            decimal.NewFromInt(200),
+						TotalExpectedReturn:      decimal.NewFromInt(12),
+						TotalPayoutsReceived:     decimal.Zero,
+						TotalOutstandingExposure: decimal.NewFromInt(200),
+					},
+				},
+			},
+		},
+		{
+			name: "GetPortfolio_Success_Empty",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`FROM "investments"`)).
+					WithArgs(investorID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"loan_id", "status", "roi", "amount_invested"}))
+				mockSql.ExpectQuery(regexp.QuoteMeta(`FROM "payouts"`)).
+					WithArgs(investorID).
+					WillReturnRows(sqlmock.NewRows([]string{"loan_id", "principal_received", "return_received", "payouts_received"}))
+			},
+			want: &entity.Portfolio{Loans: []entity.PortfolioLoan{}, Totals: []entity.PortfolioTotal{}},
+		},
+		{
+			name: "GetPortfolio_Failure_DBError",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`FROM "investments"`)).
+					WillReturnError(fmt.Errorf("DB error"))
+			},
+			wantErr: fmt.Errorf("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			u := usecase.NewInvestorUsecase(db)
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+			got, err := u.GetPortfolio(investorID)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assertSameJSON(t, tt.want, got)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
@@ -5,81 +5,208 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"loan-service/agreement"
 	"loan-service/entity"
+	"loan-service/statemachine"
 	"loan-service/utils/constants"
 	errs "loan-service/utils/errors"
 	"loan-service/utils/logger"
+	"loan-service/utils/money"
+	"strings"
 	"time"
 
-	"codeberg.org/go-pdf/fpdf"
 	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// LoanNotifier queues loan notifications with the transaction of the change, Wake is called once it is committed
+// so they are delivered outside of the request
+type LoanNotifier interface {
+	QueueLoanInvested(ctx context.Context, tx *gorm.DB, loanID uint) error
+	QueueLoanExpired(ctx context.Context, tx *gorm.DB, loanID uint) error
+	Wake()
+}
+
+// DocumentSaver keeps the documents issued for loans, the record is written with the transaction of the change
+type DocumentSaver interface {
+	SaveDocument(ctx context.Context, tx *gorm.DB, document entity.Document, body []byte) (*entity.Document, error)
+	TrackContent(ctx context.Context) (context.Context, func(committed bool))
+	Link(document entity.Document) string
+}
+
+// LoanPolicy holds the configurable rules of the loan lifecycle
+type LoanPolicy struct {
+	// InvestmentGracePeriod is how long an investment can be cancelled after it is made, zero disables cancellation
+	InvestmentGracePeriod time.Duration
+	// MinTicket and MaxTicket are the default investment ticket sizes per currency, a loan can override them on approval
+	MinTicket money.Amounts
+	MaxTicket money.Amounts
+	// MaxInvestorLoanShare is the percentage of a loan principal a single investor can hold, zero means no limit
+	MaxInvestorLoanShare decimal.Decimal
+	// MaxBorrowerExposure caps per currency the outstanding principal an investor can have in the loans of one borrower
+	MaxBorrowerExposure money.Amounts
+	// MaxBorrowerActiveLoans is how many active loans a borrower can have at once, zero means no limit
+	MaxBorrowerActiveLoans int
+	// MaxBorrowerOutstanding caps per currency the principal a borrower can have outstanding, including the new proposal
+	MaxBorrowerOutstanding money.Amounts
+	// RiskScorer grades the loans on approval, nil uses RuleScorer
+	RiskScorer RiskScorer
+	// DualApprovalThreshold is per currency the principal above which a loan needs two approvers before it is approved
+	DualApprovalThreshold money.Amounts
+	// MaxAppeals is how many rejected loans a borrower can resubmit in total, zero means no limit
+	MaxAppeals int
+}
+
 type LoanUsecase struct {
 	db          *gorm.DB
 	redisClient *redis.Client
+	notifier    LoanNotifier
+	documents   DocumentSaver
+	policy      LoanPolicy
+	machine     *statemachine.Machine
 }
 
-func NewLoanUsecase(db *gorm.DB, redisClient *redis.Client) *LoanUsecase {
-	return &LoanUsecase{
+func NewLoanUsecase(db *gorm.DB, redisClient *redis.Client, notifier LoanNotifier, documents DocumentSaver, policy LoanPolicy) *LoanUsecase {
+	if policy.RiskScorer == nil {
+		policy.RiskScorer = RuleScorer{}
+	}
+	u := &LoanUsecase{
 		db:          db,
 		redisClient: redisClient,
+		notifier:    notifier,
+		documents:   documents,
+		policy:      policy,
+		machine:     statemachine.NewLoanMachine(),
 	}
+	u.machine.Guard(statemachine.EventDefault, requireOverdueInstallment)
+	u.machine.Guard(statemachine.EventCancel, requireLoanOwner)
+	u.machine.Guard(statemachine.EventApprove, u.requireCountersignature)
+	u.machine.After(statemachine.EventCancel, voidInvestments)
+	u.machine.After(statemachine.EventExpire, voidInvestments)
+	u.machine.After(statemachine.EventExpire, u.queueExpiredNotifications)
+	u.machine.After(statemachine.EventInvest, u.writeInvestmentAgreements)
+	u.machine.After(statemachine.EventInvest, u.queueInvestedNotifications)
+	u.machine.AfterEach(recordLoanEvent)
+	return u
 }
 
-func (u *LoanUsecase) CreateLoan(loanRequest entity.RequestProposeLoan, borrowerID uint) (*entity.Loan, error) {
-	tx := u.db.Begin()
+// lockLoan guards concurrent money movements on the same loan, the returned func releases the lock. Every change
+// to the funded total or the status of a loan that has investments holds it, so an investment can not be added
+// while the loan is cancelled or expired, or while another investment is cancelled
+func (u *LoanUsecase) lockLoan(ctx context.Context, loanID uint) (func(), error) {
+	lockKey := fmt.Sprintf("event_lock:%d", loanID)
+	locked, err := u.redisClient.SetNX(ctx, lockKey, "locked", 5*time.Second).Result()
+	if err != nil {
+		return nil, errors.New(errs.ErrLockAcquisitionFailed)
+	}
+	if !locked {
+		return nil, errors.New(errs.ErrBusySystem)
+	}
+	return func() { u.redisClient.Del(ctx, lockKey) }, nil
+}
+
+func (u *LoanUsecase) CreateLoan(ctx context.Context, loanRequest entity.RequestProposeLoan, borrower entity.Actor) (*entity.Loan, error) {
+	return u.proposeLoan(ctx, loanRequest, borrower, nil, loanRequest)
+}
+
+// proposeLoan creates a proposed loan with its proposal document, previous is the rejected loan it is resubmitted for
+// if any and payload is the request recorded with the propose event
+func (u *LoanUsecase) proposeLoan(ctx context.Context, loanRequest entity.RequestProposeLoan, borrower entity.Actor, previous *entity.Loan, payload interface{}) (*entity.Loan, error) {
+	var loan *entity.Loan
+	err := retrySerializable(func() error {
+		var err error
+		loan, err = u.attemptProposeLoan(ctx, loanRequest, borrower, previous, payload)
+		return err
+	})
+	return loan, err
+}
+
+func (u *LoanUsecase) attemptProposeLoan(ctx context.Context, loanRequest entity.RequestProposeLoan, borrower entity.Actor, previous *entity.Loan, payload interface{}) (*entity.Loan, error) {
+	currency := loanRequest.Currency
+	if currency == "" {
+		currency = money.Default
+	}
+	if !money.Supported(currency) {
+		return nil, errors.New(errs.ErrUnsupportedCurrency)
+	}
+	if !money.FitsMinorUnits(loanRequest.Principal, currency) {
+		return nil, errors.New(errs.ErrInvalidMinorUnits)
+	}
+
+	// the stored agreement is removed again when the transaction does not commit
+	ctx, release := u.documents.TrackContent(ctx)
+	committed := false
+	defer func() { release(committed) }()
+
+	// the borrower limits are read and enforced in the same serializable transaction, so concurrent proposals of one
+	// borrower can not both pass them
+	tx := u.db.Begin(&sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
 	defer tx.Rollback()
 
+	if previous != nil {
+		if err := u.checkResubmission(tx, *previous); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.checkBorrowerLimits(tx, borrower.ID, loanRequest.Principal, currency); err != nil {
+		return nil, err
+	}
+
 	loan := entity.Loan{
-		Principal:  loanRequest.Principal,
-		ROI:        loanRequest.ROI,
-		Rate:       loanRequest.Rate,
-		BorrowerID: borrowerID,
-		Status:     constants.StatusProposed,
-	}
-	if err := tx.Create(&loan).Error; err != nil {
-		return nil, err
-	}
-
-	pdf := fpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
-	pdf.SetFont("Arial", "B", 16)
-	pdf.Cell(40, 10, fmt.Sprintf("Loan Proposal: ID %d", loan.ID))
-	pdf.Ln(10)
-	pdf.Cell(0, 10, fmt.Sprintf("Principal: %.2f", loan.Principal))
-	pdf.Ln(10)
-	pdf.Cell(0, 10, fmt.Sprintf("ROI: %.2f%%", loan.ROI))
-	pdf.Ln(10)
-	pdf.Cell(0, 10, fmt.Sprintf("Rate: %.2f%%", loan.Rate))
-	pdf.Ln(10)
-	pdf.Cell(0, 10, fmt.Sprintf("Borrower ID: %d", loan.BorrowerID))
-	pdf.Ln(10)
-	pdfFileName := fmt.Sprintf("loan_proposal_%d.pdf", loan.ID)
-	pdf.Ln(10)
-	if err := pdf.OutputFileAndClose(pdfFileName); err != nil {
-		logger.Error("Failed to create PDF", zap.Error(err))
-		return nil, errors.New("failed to create PDF document")
-	}
-	agreementLink := fmt.Sprintf("https://example.com/loans/%d/%s", loan.ID, pdfFileName)
+		Principal:        loanRequest.Principal,
+		Currency:         currency,
+		ROI:              loanRequest.ROI,
+		Rate:             loanRequest.Rate,
+		Tenor:            loanRequest.Tenor,
+		Frequency:        loanRequest.Frequency,
+		BorrowerID:       borrower.ID,
+		AgreementVersion: agreement.CurrentLoanVersion,
+	}
+	if loan.Tenor == 0 {
+		loan.Tenor = constants.DefaultTenor
+	}
+	if loan.Frequency == "" {
+		loan.Frequency = constants.DefaultFrequency
+	}
+	if previous != nil {
+		loan.ResubmissionOf = &previous.ID
+	}
+	if err := u.machine.Fire(ctx, tx, &loan, statemachine.EventPropose, borrower, payload); err != nil {
+		// a concurrent resubmission of the same loan was committed first
+		if previous != nil && isUniqueViolation(err) {
+			return nil, errors.New(errs.ErrLoanAlreadyResubmitted)
+		}
+		return nil, err
+	}
+
+	agreementLink, err := u.writeLoanAgreement(ctx, tx, loan)
+	if err != nil {
+		return nil, err
+	}
 	loan.AgreementLink = &agreementLink
 	if err := tx.Save(&loan).Error; err != nil {
 		logger.Error("Failed to save loan with PDF URL", zap.Error(err))
 		return nil, errors.New("failed to save loan with PDF URL")
 	}
 
-	tx.Commit()
+	if err := tx.Commit().Error; err != nil {
+		logger.Error("Failed to commit loan proposal", zap.Uint("borrowerID", borrower.ID), zap.Error(err))
+		return nil, err
+	}
+	committed = true
 
 	logger.Info("Loan created successfully", zap.Uint("loanID", loan.ID))
 
 	return &loan, nil
 }
 
-func (u *LoanUsecase) RejectLoan(rejectionRequest entity.RequestRejectLoan, validatorID uint) (*entity.LoanApproval, error) {
+func (u *LoanUsecase) RejectLoan(ctx context.Context, rejectionRequest entity.RequestRejectLoan, validator entity.Actor) (*entity.LoanApproval, error) {
 	var loan entity.Loan
-	if err := u.db.First(&loan, "id = ? AND status = ?", rejectionRequest.LoanID, constants.StatusProposed).Error; err != nil {
+	if err := u.db.First(&loan, "id = ? AND status IN ?", rejectionRequest.LoanID, u.machine.From(statemachine.EventReject)).Error; err != nil {
 		return nil, err
 	}
 	tx := u.db.Begin(&sql.TxOptions{
@@ -87,15 +214,14 @@ func (u *LoanUsecase) RejectLoan(rejectionRequest entity.RequestRejectLoan, vali
 	})
 	defer tx.Rollback()
 
-	loan.Status = constants.StatusRejected
-	if err := tx.Save(&loan).Error; err != nil {
+	if err := u.machine.Fire(ctx, tx, &loan, statemachine.EventReject, validator, rejectionRequest); err != nil {
 		return nil, err
 	}
 
 	rejection := entity.LoanApproval{
 		LoanID:       loan.ID,
 		RejectReason: &rejectionRequest.RejectReason,
-		ValidatorID:  validatorID,
+		ValidatorID:  validator.ID,
 	}
 	if err := tx.Create(&rejection).Error; err != nil {
 		return nil, err
@@ -106,27 +232,95 @@ func (u *LoanUsecase) RejectLoan(rejectionRequest entity.RequestRejectLoan, vali
 	return &rejection, nil
 }
 
-func (u *LoanUsecase) ApproveLoan(approvalRequest entity.RequestApproveLoan, validatorID uint) (*entity.LoanApproval, error) {
+func (u *LoanUsecase) ApproveLoan(ctx context.Context, approvalRequest entity.RequestApproveLoan, validator entity.Actor) (*entity.LoanApproval, error) {
+	if approvalRequest.RiskGrade != "" {
+		if !validRiskGrade(approvalRequest.RiskGrade) {
+			return nil, errors.New(errs.ErrInvalidRiskGrade)
+		}
+		if strings.TrimSpace(approvalRequest.RiskGradeJustification) == "" {
+			return nil, errors.New(errs.ErrRiskGradeJustificationRequired)
+		}
+	}
+
 	var loan entity.Loan
-	if err := u.db.First(&loan, "id = ? AND status = ?", approvalRequest.LoanID, constants.StatusProposed).Error; err != nil {
+	if err := u.db.First(&loan, "id = ? AND status IN ?", approvalRequest.LoanID, u.machine.From(statemachine.EventApprove)).Error; err != nil {
+		return nil, err
+	}
+	if !validTicketSize(loan, approvalRequest.MinTicket, approvalRequest.MaxTicket) {
+		return nil, errors.New(errs.ErrInvalidTicketSize)
+	}
+	photo, err := attachedDocument(u.db, approvalRequest.PhotoDocumentID, constants.DocumentApprovalPhoto, loan.ID)
+	if err != nil {
 		return nil, err
 	}
+
+	history, err := borrowerHistory(u.db, loan)
+	if err != nil {
+		logger.Error("Failed to fetch borrower history", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+	assessment, err := u.policy.RiskScorer.Score(ctx, loan, history)
+	if err != nil {
+		logger.Error("Failed to score loan risk", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+
+	approval := entity.LoanApproval{
+		LoanID:          loan.ID,
+		ValidatorID:     validator.ID,
+		PhotoURL:        u.documents.Link(*photo),
+		PhotoDocumentID: &photo.ID,
+		RiskScore:       &assessment.Score,
+		ScoredRiskGrade: assessment.Grade,
+		RiskGrade:       assessment.Grade,
+	}
+	if approvalRequest.RiskGrade != "" {
+		approval.RiskGrade = approvalRequest.RiskGrade
+		approval.RiskGradeJustification = &approvalRequest.RiskGradeJustification
+	}
+
 	tx := u.db.Begin(&sql.TxOptions{
 		Isolation: sql.LevelSerializable,
 	})
 	defer tx.Rollback()
 
-	loan.Status = constants.StatusApproved
-	if err := tx.Save(&loan).Error; err != nil {
-		return nil, err
+	// a large loan only moves to approved once enough approvers signed it off, until then the sign-off is recorded
+	if u.needsDualApproval(loan) {
+		if err := u.machine.Can(statemachine.EventApprove, loan.Status, validator.Role); err != nil {
+			return nil, err
+		}
+		approval.Signoffs, err = signOff(tx, entity.ApprovalSignoff{
+			LoanID:                 loan.ID,
+			ApproverID:             validator.ID,
+			Role:                   validator.Role,
+			PhotoDocumentID:        approval.PhotoDocumentID,
+			RiskGrade:              approval.RiskGrade,
+			RiskGradeJustification: approval.RiskGradeJustification,
+			MinTicket:              approvalRequest.MinTicket,
+			MaxTicket:              approvalRequest.MaxTicket,
+		})
+		if err != nil {
+			logger.Error("Failed to record approval sign-off", zap.Uint("loanID", loan.ID), zap.Error(err))
+			return nil, err
+		}
+		if !quorumReached(approval.Signoffs) {
+			if err := tx.Commit().Error; err != nil {
+				logger.Error("Failed to commit approval sign-off", zap.Uint("loanID", loan.ID), zap.Error(err))
+				return nil, err
+			}
+			approval.Pending = true
+			return &approval, nil
+		}
 	}
 
-	approval := entity.LoanApproval{
-		LoanID:      loan.ID,
-		ValidatorID: validatorID,
-		PhotoURL:    approvalRequest.PhotoURL,
-		ApprovedAt:  time.Now(),
+	// the ticket sizes are only set on the loan once it is approved, every sign-off of a large loan agreed on them
+	loan.MinTicket = approvalRequest.MinTicket
+	loan.MaxTicket = approvalRequest.MaxTicket
+	if err := u.machine.Fire(ctx, tx, &loan, statemachine.EventApprove, validator, approvalRequest); err != nil {
+		return nil, err
 	}
+
+	approval.ApprovedAt = time.Now()
 	if err := tx.Create(&approval).Error; err != nil {
 		return nil, err
 	}
@@ -139,78 +333,132 @@ func (u *LoanUsecase) ApproveLoan(approvalRequest entity.RequestApproveLoan, val
 func (u *LoanUsecase) AddInvestment(
 	ctx context.Context,
 	investmentRequest entity.RequestAddInvestment,
-	investorID uint,
+	investor entity.Actor,
+) (*entity.Investment, error) {
+	var investment *entity.Investment
+	err := retrySerializable(func() error {
+		var err error
+		investment, err = u.attemptAddInvestment(ctx, investmentRequest, investor)
+		return err
+	})
+	return investment, err
+}
+
+func (u *LoanUsecase) attemptAddInvestment(
+	ctx context.Context,
+	investmentRequest entity.RequestAddInvestment,
+	investor entity.Actor,
 ) (*entity.Investment, error) {
 	var loan entity.Loan
-	lockKey := fmt.Sprintf("event_lock:%d", investmentRequest.LoanID)
-	locked, err := u.redisClient.SetNX(ctx, lockKey, "locked", 5*time.Second).Result()
+	unlock, err := u.lockLoan(ctx, investmentRequest.LoanID)
 	if err != nil {
-		return nil, errors.New(errs.ErrLockAcquisitionFailed)
-	}
-	if !locked {
-		return nil, errors.New(errs.ErrBusySystem)
+		return nil, err
 	}
-	defer u.redisClient.Del(ctx, lockKey)
+	defer unlock()
+
+	// the investment agreements stored once the loan is fully invested are removed again when the transaction does
+	// not commit
+	ctx, release := u.documents.TrackContent(ctx)
+	committed := false
+	defer func() { release(committed) }()
 
 	tx := u.db.Begin(&sql.TxOptions{
 		Isolation: sql.LevelSerializable,
 	})
 	defer tx.Rollback()
 
-	if err := tx.Preload("Investments").First(&loan, "id = ? AND status = ?", investmentRequest.LoanID, constants.StatusApproved).Error; err != nil {
+	if err := tx.Preload("Investments", "status = ?", constants.InvestmentActive).First(&loan, "id = ? AND status IN ?", investmentRequest.LoanID, u.machine.From(statemachine.EventInvest)).Error; err != nil {
 		return nil, err
 	}
 
-	total := 0.0
+	// an investment without a currency is in the platform currency, it is never converted to the loan currency
+	currency := investmentRequest.Currency
+	if currency == "" {
+		currency = money.Default
+	}
+	if currency != loan.Currency {
+		return nil, errors.New(errs.ErrCurrencyMismatch)
+	}
+	if !money.FitsMinorUnits(investmentRequest.Amount, loan.Currency) {
+		return nil, errors.New(errs.ErrInvalidMinorUnits)
+	}
+
+	funded := investmentRequest.Amount
 	for _, inv := range loan.Investments {
-		total += inv.Amount
+		funded = funded.Add(inv.Amount)
 	}
-	if total+investmentRequest.Amount > loan.Principal {
+	if funded.GreaterThan(loan.Principal) {
 		return nil, errors.New(errs.ErrInvestmentExceedsPrincipal)
 	}
+	remaining := loan.Principal.Sub(funded).Add(investmentRequest.Amount)
+	if err := u.checkTicketSize(loan, investmentRequest.Amount, remaining); err != nil {
+		return nil, err
+	}
+	if err := u.checkConcentration(tx, loan, investor.ID, investmentRequest.Amount); err != nil {
+		return nil, err
+	}
 
 	investment := entity.Investment{
 		LoanID:     investmentRequest.LoanID,
-		InvestorID: investorID,
+		InvestorID: investor.ID,
 		Amount:     investmentRequest.Amount,
+		Currency:   loan.Currency,
+		Status:     constants.InvestmentActive,
 	}
 	if err := tx.Create(&investment).Error; err != nil {
 		return nil, err
 	}
-	if total+investment.Amount == loan.Principal {
-		loan.Status = constants.StatusInvested
-		if err := tx.Save(&loan).Error; err != nil {
+	fullyInvested := funded.Equal(loan.Principal)
+	if fullyInvested {
+		if err := u.machine.Fire(ctx, tx, &loan, statemachine.EventInvest, investor, investment); err != nil {
 			return nil, err
 		}
 	}
 
-	tx.Commit()
+	if err := tx.Commit().Error; err != nil {
+		logger.Error("Failed to commit investment", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+	committed = true
+
+	if fullyInvested {
+		u.notifier.Wake()
+	}
 
 	return &investment, nil
 }
 
-func (u *LoanUsecase) DisburseLoan(disbursementRequest entity.RequestDisburseLoan, disburserID uint) (*entity.LoanDisbursement, error) {
+// queueInvestedNotifications runs after writeInvestmentAgreements so the emails carry the agreement links
+func (u *LoanUsecase) queueInvestedNotifications(ctx context.Context, change statemachine.Change) error {
+	return u.notifier.QueueLoanInvested(ctx, change.Tx, change.Loan.ID)
+}
+
+func (u *LoanUsecase) DisburseLoan(ctx context.Context, disbursementRequest entity.RequestDisburseLoan, disburser entity.Actor) (*entity.LoanDisbursement, error) {
 	var loan entity.Loan
-	if err := u.db.First(&loan, "id = ? AND status = ?", disbursementRequest.LoanID, constants.StatusInvested).Error; err != nil {
+	if err := u.db.First(&loan, "id = ? AND status IN ?", disbursementRequest.LoanID, u.machine.From(statemachine.EventDisburse)).Error; err != nil {
 		logger.Error("Failed to find loan for disbursement", zap.Uint("loanID", disbursementRequest.LoanID), zap.Error(err))
 		return nil, err
 	}
-	loan.Status = constants.StatusDisbursed
 	disbursementRequest.LoanID = loan.ID
+	signedAgreement, err := attachedDocument(u.db, disbursementRequest.SignedAgreementDocumentID, constants.DocumentSignedAgreement, loan.ID)
+	if err != nil {
+		return nil, err
+	}
 
 	tx := u.db.Begin()
 	defer tx.Rollback()
 
-	if err := tx.Save(&loan).Error; err != nil {
+	if err := u.machine.Fire(ctx, tx, &loan, statemachine.EventDisburse, disburser, disbursementRequest); err != nil {
 		logger.Error("Failed to update loan status to disbursed", zap.Uint("loanID", disbursementRequest.LoanID), zap.Error(err))
 		return nil, err
 	}
 
 	disbursement := entity.LoanDisbursement{
-		LoanID:             disbursementRequest.LoanID,
-		SignedAgreementURL: disbursementRequest.SignedAgreementURL,
-		DisburserID:        disburserID,
-		DisbursedAt:        time.Now(),
+		LoanID:                    disbursementRequest.LoanID,
+		SignedAgreementURL:        u.documents.Link(*signedAgreement),
+		SignedAgreementDocumentID: &signedAgreement.ID,
+		DisburserID:               disburser.ID,
+		DisbursedAt:               time.Now(),
 	}
 
 	if err := tx.Create(&disbursement).Error; err != nil {
@@ -218,6 +466,16 @@ func (u *LoanUsecase) DisburseLoan(disbursementRequest entity.RequestDisburseLoa
 		return nil, err
 	}
 
+	installments, err := GenerateInstallments(loan, disbursement.DisbursedAt)
+	if err != nil {
+		logger.Error("Failed to generate repayment schedule", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+	if err := tx.Create(&installments).Error; err != nil {
+		logger.Error("Failed to create repayment schedule", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+
 	tx.Commit()
 	return &disbursement, nil
 }
@@ -228,5 +486,33 @@ func (u *LoanUsecase) GetLoan(loanID string) (*entity.Loan, error) {
 		logger.Error("Failed to fetch loan by ID", zap.String("loanID", loanID), zap.Error(err))
 		return nil, err
 	}
+	if loan.ResubmissionOf != nil {
+		if err := u.loadResubmission(&loan); err != nil {
+			logger.Error("Failed to fetch rejected loan of resubmission", zap.String("loanID", loanID), zap.Error(err))
+			return nil, err
+		}
+	}
 	return &loan, nil
 }
+
+func (u *LoanUsecase) GetSchedule(loanID string) ([]entity.Installment, error) {
+	var loan entity.Loan
+	if err := u.db.First(&loan, "id = ?", loanID).Error; err != nil {
+		logger.Error("Failed to fetch loan by ID", zap.String("loanID", loanID), zap.Error(err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(errs.ErrLoanNotFound)
+		}
+		return nil, err
+	}
+
+	var installments []entity.Installment
+	if err := u.db.Where("loan_id = ?", loan.ID).Order("sequence").Find(&installments).Error; err != nil {
+		logger.Error("Failed to fetch repayment schedule", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+	if len(installments) == 0 {
+		return nil, errors.New(errs.ErrScheduleNotAvailable)
+	}
+
+	return installments, nil
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"loan-service/entity"
+	"loan-service/statemachine"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/logger"
+	"loan-service/utils/requestid"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+// recordLoanEvent keeps the audit trail of every loan status transition, it is stored in the transaction
+// of the transition so a change is never committed without its event
+func recordLoanEvent(ctx context.Context, change statemachine.Change) error {
+	payload, err := json.Marshal(change.Payload)
+	if err != nil {
+		return err
+	}
+
+	event := entity.LoanEvent{
+		LoanID:     change.Loan.ID,
+		Event:      string(change.Event),
+		FromStatus: change.From,
+		ToStatus:   change.To,
+		ActorID:    change.Actor.ID,
+		ActorRole:  change.Actor.Role,
+		RequestID:  requestid.FromContext(ctx),
+		Payload:    payload,
+	}
+	if err := change.Tx.Create(&event).Error; err != nil {
+		logger.Error("Failed to record loan event", zap.Uint("loanID", change.Loan.ID), zap.String("event", event.Event), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// GetHistory returns the status transitions of a loan from the oldest one, only the staff and the borrower
+// of the loan can read them
+func (u *LoanUsecase) GetHistory(loanID string, actor entity.Actor) ([]entity.LoanEvent, error) {
+	var loan entity.Loan
+	if err := u.db.First(&loan, "id = ?", loanID).Error; err != nil {
+		logger.Error("Failed to fetch loan by ID", zap.String("loanID", loanID), zap.Error(err))
+		return nil, errors.New(errs.ErrLoanNotFound)
+	}
+	if !slices.Contains(staffRoles, actor.Role) && loan.BorrowerID != actor.ID {
+		return nil, errors.New(errs.ErrUnauthorizedAction)
+	}
+
+	events := []entity.LoanEvent{}
+	if err := u.db.Where("loan_id = ?", loan.ID).Order("id").Find(&events).Error; err != nil {
+		logger.Error("Failed to fetch loan history", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+	return events, nil
+}
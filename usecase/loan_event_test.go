@@ -0,0 +1,183 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loan-service/entity"
+	"loan-service/usecase"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/requestid"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestLoanUsecase_RecordLoanEvent(t *testing.T) {
+	loanID := uint(1)
+	validatorID := uint(2)
+	tests := []struct {
+		name     string
+		mockFunc func(mockSql sqlmock.Sqlmock)
+		wantErr  bool
+	}{
+		{
+			name: "RecordLoanEvent_Success",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "loan_events"`)).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						loanID,
+						"reject",
+						constants.StatusProposed,
+						constants.StatusRejected,
+						validatorID,
+						constants.RoleValidator,
+						"req-1",
+						[]byte(`{"loan_id":1,"reject_reason":"Insufficient credit score"}`),
+					).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "loan_approvals"`)).
+					WillReturnRows(sqlmock.NewRows([]string{"loan_id"}).AddRow(loanID))
+				mockSql.ExpectCommit()
+			},
+		},
+		{
+			name: "RecordLoanEvent_Failure_RollsBackTransition",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "loan_events"`)).
+					WillReturnError(errors.New("insert failed"))
+				mockSql.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			redis, _ := redismock.NewClientMock()
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, usecase.LoanPolicy{})
+
+			mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans"`)).
+				WithArgs(1, constants.StatusProposed, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(loanID, constants.StatusProposed))
+			mockSql.ExpectBegin()
+			mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "loans"`)).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			tt.mockFunc(mockSql)
+
+			ctx := context.WithValue(context.Background(), requestid.ContextKey, "req-1")
+			_, err := u.RejectLoan(ctx, entity.RequestRejectLoan{
+				LoanID:       loanID,
+				RejectReason: "Insufficient credit score",
+			}, entity.Actor{ID: validatorID, Role: constants.RoleValidator})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLoanUsecase_GetHistory(t *testing.T) {
+	tests := []struct {
+		name     string
+		loanID   string
+		actor    entity.Actor
+		mockFunc func(mockSql sqlmock.Sqlmock)
+		want     []entity.LoanEvent
+		wantErr  error
+	}{
+		{
+			name:   "GetHistory_Success_Borrower",
+			loanID: "1",
+			actor:  entity.Actor{ID: 2, Role: constants.RoleBorrower},
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans" WHERE id = $1 ORDER BY "loans"."id" LIMIT $2`)).
+					WithArgs("1", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "borrower_id", "status"}).AddRow(1, 2, constants.StatusApproved))
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loan_events" WHERE loan_id = $1 ORDER BY id`)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "event", "from_status", "to_status", "actor_id", "actor_role", "request_id", "payload"}).
+						AddRow(1, 1, "propose", "", constants.StatusProposed, 2, constants.RoleBorrower, "req-1", []byte(`{"principal":1000}`)).
+						AddRow(2, 1, "approve", constants.StatusProposed, constants.StatusApproved, 3, constants.RoleValidator, "req-2", []byte(`{"loan_id":1}`)))
+			},
+			want: []entity.LoanEvent{
+				{
+					DBCommon:  entity.DBCommon{ID: 1},
+					LoanID:    1,
+					Event:     "propose",
+					ToStatus:  constants.StatusProposed,
+					ActorID:   2,
+					ActorRole: constants.RoleBorrower,
+					RequestID: "req-1",
+					Payload:   []byte(`{"principal":1000}`),
+				},
+				{
+					DBCommon:   entity.DBCommon{ID: 2},
+					LoanID:     1,
+					Event:      "approve",
+					FromStatus: constants.StatusProposed,
+					ToStatus:   constants.StatusApproved,
+					ActorID:    3,
+					ActorRole:  constants.RoleValidator,
+					RequestID:  "req-2",
+					Payload:    []byte(`{"loan_id":1}`),
+				},
+			},
+		},
+		{
+			name:   "GetHistory_Failure_NotInvolvedInLoan",
+			loanID: "1",
+			actor:  entity.Actor{ID: 3, Role: constants.RoleInvestor},
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs("1", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "borrower_id", "status"}).AddRow(1, 2, constants.StatusApproved))
+			},
+			wantErr: fmt.Errorf(errs.ErrUnauthorizedAction),
+		},
+		{
+			name:   "GetHistory_Failure_LoanNotFound",
+			loanID: "1",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs("1", 1).
+					WillReturnError(gorm.ErrRecordNotFound)
+			},
+			wantErr: fmt.Errorf(errs.ErrLoanNotFound),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			redis, _ := redismock.NewClientMock()
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, usecase.LoanPolicy{})
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+			got, err := u.GetHistory(tt.loanID, tt.actor)
+			if tt.wantErr != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
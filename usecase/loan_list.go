@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/logger"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// loanCursor points at the last loan of a page, it is only valid for the sorting it was issued with
+type loanCursor struct {
+	SortBy    string           `json:"sort_by"`
+	Order     string           `json:"order"`
+	CreatedAt *time.Time       `json:"created_at,omitempty"`
+	Principal *decimal.Decimal `json:"principal,omitempty"`
+	ID        uint             `json:"id"`
+}
+
+func encodeLoanCursor(cursor loanCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeLoanCursor(encoded string) (*loanCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	var cursor loanCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// loanPage is the keyset pagination shared by the loan listings, loans are sorted by created_at or principal
+// with id as tie breaker
+type loanPage struct {
+	sortBy string
+	order  string
+	limit  int
+	cursor *loanCursor
+}
+
+func newLoanPage(sortBy, order string, limit int, cursor string) (*loanPage, error) {
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	if sortBy != "created_at" && sortBy != "principal" {
+		return nil, errors.New(errs.ErrInvalidLoanFilter)
+	}
+	if order == "" {
+		order = "desc"
+	}
+	if order != "asc" && order != "desc" {
+		return nil, errors.New(errs.ErrInvalidLoanFilter)
+	}
+	if limit <= 0 {
+		limit = constants.DefaultLoanPageSize
+	}
+
+	page := &loanPage{sortBy: sortBy, order: order, limit: limit}
+	if cursor == "" {
+		return page, nil
+	}
+
+	decoded, err := decodeLoanCursor(cursor)
+	if err != nil || decoded.SortBy != sortBy || decoded.Order != order {
+		return nil, errors.New(errs.ErrInvalidCursor)
+	}
+	if (sortBy == "created_at" && decoded.CreatedAt == nil) || (sortBy == "principal" && decoded.Principal == nil) {
+		return nil, errors.New(errs.ErrInvalidCursor)
+	}
+	page.cursor = decoded
+	return page, nil
+}
+
+// apply adds the cursor condition, sorting and limit to the query. One extra row is fetched to know whether
+// there is a next page. The table prefix is needed when the loans are joined with other tables.
+func (p *loanPage) apply(query *gorm.DB, table string) *gorm.DB {
+	sortColumn, idColumn := p.sortBy, "id"
+	if table != "" {
+		sortColumn, idColumn = table+"."+sortColumn, table+"."+idColumn
+	}
+
+	if p.cursor != nil {
+		var value interface{} = *p.cursor.CreatedAt
+		if p.sortBy == "principal" {
+			value = *p.cursor.Principal
+		}
+		comparison := ">"
+		if p.order == "desc" {
+			comparison = "<"
+		}
+		query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", sortColumn, idColumn, comparison), value, p.cursor.ID)
+	}
+
+	return query.Order(fmt.Sprintf("%s %s, %s %s", sortColumn, p.order, idColumn, p.order)).Limit(p.limit + 1)
+}
+
+// nextCursor returns the cursor of the page following the given last loan, or an empty string if
+// the fetched rows fit in the page
+func (p *loanPage) nextCursor(fetched int, id uint, createdAt time.Time, principal decimal.Decimal) string {
+	if fetched <= p.limit {
+		return ""
+	}
+
+	next := loanCursor{SortBy: p.sortBy, Order: p.order, ID: id}
+	if p.sortBy == "created_at" {
+		next.CreatedAt = &createdAt
+	} else {
+		next.Principal = &principal
+	}
+	return encodeLoanCursor(next)
+}
+
+// validPrincipalRange reports whether the optional principal bounds are not negative and not inverted
+func validPrincipalRange(minPrincipal, maxPrincipal *decimal.Decimal) bool {
+	if (minPrincipal != nil && minPrincipal.IsNegative()) || (maxPrincipal != nil && maxPrincipal.IsNegative()) {
+		return false
+	}
+	return minPrincipal == nil || maxPrincipal == nil || !minPrincipal.GreaterThan(*maxPrincipal)
+}
+
+// ListLoans returns a page of loans matching the filter, the returned cursor is empty on the last page
+func (u *LoanUsecase) ListLoans(listRequest entity.RequestListLoans) ([]entity.Loan, string, error) {
+	page, err := newLoanPage(listRequest.SortBy, listRequest.Order, listRequest.Limit, listRequest.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !validPrincipalRange(listRequest.MinPrincipal, listRequest.MaxPrincipal) {
+		return nil, "", errors.New(errs.ErrInvalidLoanFilter)
+	}
+	if listRequest.CreatedFrom != nil && listRequest.CreatedTo != nil && listRequest.CreatedFrom.After(*listRequest.CreatedTo) {
+		return nil, "", errors.New(errs.ErrInvalidLoanFilter)
+	}
+
+	query := u.db.Model(&entity.Loan{})
+	if len(listRequest.Status) > 0 {
+		query = query.Where("status IN ?", listRequest.Status)
+	}
+	if listRequest.BorrowerID != 0 {
+		query = query.Where("borrower_id = ?", listRequest.BorrowerID)
+	}
+	if listRequest.InvestorID != 0 {
+		invested := u.db.Model(&entity.Investment{}).Select("loan_id").Where("investor_id = ?", listRequest.InvestorID)
+		query = query.Where("status = ? OR id IN (?)", constants.StatusApproved, invested)
+	}
+	if listRequest.Currency != "" {
+		query = query.Where("currency = ?", listRequest.Currency)
+	}
+	if listRequest.MinPrincipal != nil {
+		query = query.Where("principal >= ?", *listRequest.MinPrincipal)
+	}
+	if listRequest.MaxPrincipal != nil {
+		query = query.Where("principal <= ?", *listRequest.MaxPrincipal)
+	}
+	if listRequest.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *listRequest.CreatedFrom)
+	}
+	if listRequest.CreatedTo != nil {
+		query = query.Where("created_at <= ?", *listRequest.CreatedTo)
+	}
+
+	loans := make([]entity.Loan, 0, page.limit+1)
+	if err := page.apply(query, "").Find(&loans).Error; err != nil {
+		logger.Error("Failed to list loans", zap.Error(err))
+		return nil, "", err
+	}
+
+	if len(loans) <= page.limit {
+		return loans, "", nil
+	}
+
+	last := loans[page.limit-1]
+	return loans[:page.limit], page.nextCursor(len(loans), last.ID, last.CreatedAt, last.Principal), nil
+}
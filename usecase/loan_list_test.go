@@ -0,0 +1,173 @@
+package usecase_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"loan-service/entity"
+	"loan-service/usecase"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-redis/redismock/v9"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoanUsecase_ListLoans(t *testing.T) {
+	createdAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	minPrincipal, maxPrincipal := decimal.NewFromInt(1000), decimal.NewFromInt(5000)
+	tests := []struct {
+		name           string
+		request        entity.RequestListLoans
+		mockFunc       func(mockSql sqlmock.Sqlmock)
+		wantIDs        []uint
+		wantNextCursor bool
+		wantErr        error
+	}{
+		{
+			name: "ListLoans_Success_Defaults",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans" ORDER BY created_at desc, id desc LIMIT $1`)).
+					WithArgs(constants.DefaultLoanPageSize + 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "created_at"}).
+						AddRow(2, constants.StatusProposed, createdAt).
+						AddRow(1, constants.StatusApproved, createdAt))
+			},
+			wantIDs: []uint{2, 1},
+		},
+		{
+			name: "ListLoans_Success_Filtered",
+			request: entity.RequestListLoans{
+				Status:       []constants.LoanStatus{constants.StatusProposed},
+				BorrowerID:   1,
+				MinPrincipal: &minPrincipal,
+				MaxPrincipal: &maxPrincipal,
+				SortBy:       "principal",
+				Order:        "asc",
+				Limit:        1,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans" WHERE status IN ($1) AND borrower_id = $2 AND principal >= $3 AND principal <= $4 ORDER BY principal asc, id asc LIMIT $5`)).
+					WithArgs(constants.StatusProposed, 1, minPrincipal, maxPrincipal, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "principal"}).
+						AddRow(3, constants.StatusProposed, 1500).
+						AddRow(4, constants.StatusProposed, 2000))
+			},
+			wantIDs:        []uint{3},
+			wantNextCursor: true,
+		},
+		{
+			name: "ListLoans_Success_InvestorScope",
+			request: entity.RequestListLoans{
+				Status:     []constants.LoanStatus{constants.StatusRepaying},
+				InvestorID: 3,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans" WHERE status IN ($1) AND (status = $2 OR id IN (SELECT "loan_id" FROM "investments" WHERE investor_id = $3)) ORDER BY created_at desc, id desc LIMIT $4`)).
+					WithArgs(constants.StatusRepaying, constants.StatusApproved, 3, constants.DefaultLoanPageSize+1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "created_at"}).
+						AddRow(6, constants.StatusRepaying, createdAt))
+			},
+			wantIDs: []uint{6},
+		},
+		{
+			name: "ListLoans_Failure_InvalidPrincipalRange",
+			request: entity.RequestListLoans{
+				MinPrincipal: &maxPrincipal,
+				MaxPrincipal: &minPrincipal,
+			},
+			wantErr: fmt.Errorf(errs.ErrInvalidLoanFilter),
+		},
+		{
+			name: "ListLoans_Failure_NegativePrincipal",
+			request: entity.RequestListLoans{
+				MinPrincipal: func() *decimal.Decimal { v := decimal.NewFromInt(-1); return &v }(),
+			},
+			wantErr: fmt.Errorf(errs.ErrInvalidLoanFilter),
+		},
+		{
+			name: "ListLoans_Failure_InvalidCursor",
+			request: entity.RequestListLoans{
+				Cursor: "not-a-cursor",
+			},
+			wantErr: fmt.Errorf(errs.ErrInvalidCursor),
+		},
+		{
+			name: "ListLoans_Failure_CursorFromOtherSorting",
+			request: entity.RequestListLoans{
+				Cursor: base64.RawURLEncoding.EncodeToString([]byte(`{"sort_by":"principal","order":"asc","principal":1500,"id":3}`)),
+			},
+			wantErr: fmt.Errorf(errs.ErrInvalidCursor),
+		},
+		{
+			name: "ListLoans_Failure_DBError",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans"`)).
+					WillReturnError(fmt.Errorf("DB error"))
+			},
+			wantErr: fmt.Errorf("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			redis, _ := redismock.NewClientMock()
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, usecase.LoanPolicy{})
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+			got, nextCursor, err := u.ListLoans(tt.request)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				ids := make([]uint, 0, len(got))
+				for _, loan := range got {
+					ids = append(ids, loan.ID)
+				}
+				assert.Equal(t, tt.wantIDs, ids)
+				assert.Equal(t, tt.wantNextCursor, nextCursor != "")
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLoanUsecase_ListLoans_NextPage(t *testing.T) {
+	db, mockSql := setupMockDB(t)
+	redis, _ := redismock.NewClientMock()
+	u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, usecase.LoanPolicy{})
+	createdAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	mockSql.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "loans" WHERE status IN ($1) ORDER BY created_at desc, id desc LIMIT $2`)).
+		WithArgs(constants.StatusApproved, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "created_at"}).
+			AddRow(5, constants.StatusApproved, createdAt).
+			AddRow(4, constants.StatusApproved, createdAt))
+	request := entity.RequestListLoans{Status: []constants.LoanStatus{constants.StatusApproved}, Limit: 1}
+	_, cursor, err := u.ListLoans(request)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, cursor)
+
+	mockSql.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "loans" WHERE status IN ($1) AND (created_at, id) < ($2, $3) ORDER BY created_at desc, id desc LIMIT $4`)).
+		WithArgs(constants.StatusApproved, createdAt, 5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "created_at"}).
+			AddRow(4, constants.StatusApproved, createdAt))
+	request.Cursor = cursor
+	got, cursor, err := u.ListLoans(request)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, uint(4), got[0].ID)
+	assert.Empty(t, cursor)
+	assert.NoError(t, mockSql.ExpectationsWereMet())
+}
@@ -3,17 +3,21 @@ package usecase_test
 import (
 	"context"
 	"fmt"
+	"loan-service/agreement"
 	"loan-service/entity"
+	"loan-service/statemachine"
 	"loan-service/usecase"
 	"loan-service/utils/constants"
 	errs "loan-service/utils/errors"
-	"os"
+	"loan-service/utils/money"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-redis/redismock/v9"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 )
@@ -21,21 +25,24 @@ import (
 func TestLoanUsecase_CreateLoan(t *testing.T) {
 	const loanID = 1
 	const borrowerID = 2
-	const roi = 5.0
-	const rate = 10.0
-	const principal = 1000.0
+	roi := decimal.NewFromInt(5)
+	rate := decimal.NewFromInt(10)
+	principal := decimal.NewFromInt(1000)
 
 	type args struct {
 		loanRequest entity.RequestProposeLoan
 		borrowerID  uint
 	}
 	tests := []struct {
-		name      string
-		args      args
-		mockFunc  func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock)
-		doCleanup bool
-		want      *entity.Loan
-		wantErr   error
+		name         string
+		args         args
+		policy       usecase.LoanPolicy
+		mockFunc     func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock)
+		wantDocument bool
+		// wantDiscarded is the number of agreements removed again because their transaction did not commit
+		wantDiscarded int
+		want          *entity.Loan
+		wantErr       error
 	}{
 		{
 			name: "CreateLoan_Success",
@@ -56,12 +63,21 @@ func TestLoanUsecase_CreateLoan(t *testing.T) {
 						sqlmock.AnyArg(),
 						borrowerID,
 						principal,
+						money.IDR,
 						rate,
 						roi,
+						constants.DefaultTenor,
+						constants.DefaultFrequency,
 						constants.StatusProposed,
 						nil,
+						agreement.CurrentLoanVersion,
+						nil,
+						nil,
+						nil,
 					).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(loanID))
+				expectLoanEvent(mockSql, loanID, statemachine.EventPropose, statemachine.None, constants.StatusProposed, entity.Actor{ID: borrowerID, Role: constants.RoleBorrower})
+				expectAgreementBorrower(mockSql, borrowerID)
 				mockSql.ExpectExec(regexp.QuoteMeta(
 					`UPDATE "loans"`)).
 					WithArgs(
@@ -69,16 +85,23 @@ func TestLoanUsecase_CreateLoan(t *testing.T) {
 						sqlmock.AnyArg(),
 						borrowerID,
 						principal,
+						money.IDR,
 						rate,
 						roi,
+						constants.DefaultTenor,
+						constants.DefaultFrequency,
 						constants.StatusProposed,
-						fmt.Sprintf("https://example.com/loans/%d/loan_proposal_%d.pdf", loanID, loanID),
+						"https://documents.test/1",
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
 						loanID,
 					).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 				mockSql.ExpectCommit()
 			},
-			doCleanup: true,
+			wantDocument: true,
 			want: &entity.Loan{
 				DBCommon: entity.DBCommon{
 					ID: loanID,
@@ -88,10 +111,73 @@ func TestLoanUsecase_CreateLoan(t *testing.T) {
 				Rate:          rate,
 				BorrowerID:    borrowerID,
 				Status:        constants.StatusProposed,
-				AgreementLink: &[]string{fmt.Sprintf("https://example.com/loans/%d/loan_proposal_%d.pdf", loanID, loanID)}[0],
+				AgreementLink: &[]string{"https://documents.test/1"}[0],
 			},
 			wantErr: nil,
 		},
+		{
+			name: "CreateLoan_Failure_ConcurrentProposalConflict",
+			args: args{
+				loanRequest: entity.RequestProposeLoan{
+					Principal: principal,
+					ROI:       roi,
+					Rate:      rate,
+				},
+				borrowerID: borrowerID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				// a concurrent proposal of the same borrower read the same limits, every serializable commit fails
+				for attempt := 0; attempt < constants.MaxSerializableAttempts; attempt++ {
+					mockSql.ExpectBegin()
+					mockSql.ExpectQuery(regexp.QuoteMeta(
+						`INSERT INTO "loans"`)).
+						WithArgs(
+							sqlmock.AnyArg(),
+							sqlmock.AnyArg(),
+							borrowerID,
+							principal,
+							money.IDR,
+							rate,
+							roi,
+							constants.DefaultTenor,
+							constants.DefaultFrequency,
+							constants.StatusProposed,
+							nil,
+							agreement.CurrentLoanVersion,
+							nil,
+							nil,
+							nil,
+						).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(loanID))
+					expectLoanEvent(mockSql, loanID, statemachine.EventPropose, statemachine.None, constants.StatusProposed, entity.Actor{ID: borrowerID, Role: constants.RoleBorrower})
+					expectAgreementBorrower(mockSql, borrowerID)
+					mockSql.ExpectExec(regexp.QuoteMeta(
+						`UPDATE "loans"`)).
+						WithArgs(
+							sqlmock.AnyArg(),
+							sqlmock.AnyArg(),
+							borrowerID,
+							principal,
+							money.IDR,
+							rate,
+							roi,
+							constants.DefaultTenor,
+							constants.DefaultFrequency,
+							constants.StatusProposed,
+							"https://documents.test/1",
+							sqlmock.AnyArg(),
+							sqlmock.AnyArg(),
+							sqlmock.AnyArg(),
+							sqlmock.AnyArg(),
+							loanID,
+						).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+					mockSql.ExpectCommit().WillReturnError(&pgconn.PgError{Code: "40001", Message: "could not serialize access due to read/write dependencies among transactions"})
+				}
+			},
+			wantDiscarded: constants.MaxSerializableAttempts,
+			wantErr:       fmt.Errorf(errs.ErrConcurrentUpdate),
+		},
 		{
 			name: "CreateLoan_Failure_DBError_CreateLoan",
 			args: args{
@@ -111,10 +197,17 @@ func TestLoanUsecase_CreateLoan(t *testing.T) {
 						sqlmock.AnyArg(),
 						borrowerID,
 						principal,
+						money.IDR,
 						rate,
 						roi,
+						constants.DefaultTenor,
+						constants.DefaultFrequency,
 						constants.StatusProposed,
 						nil,
+						agreement.CurrentLoanVersion,
+						nil,
+						nil,
+						nil,
 					).
 					WillReturnError(fmt.Errorf("DB error"))
 				mockSql.ExpectRollback()
@@ -140,12 +233,21 @@ func TestLoanUsecase_CreateLoan(t *testing.T) {
 						sqlmock.AnyArg(),
 						borrowerID,
 						principal,
+						money.IDR,
 						rate,
 						roi,
+						constants.DefaultTenor,
+						constants.DefaultFrequency,
 						constants.StatusProposed,
 						nil,
+						agreement.CurrentLoanVersion,
+						nil,
+						nil,
+						nil,
 					).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(loanID))
+				expectLoanEvent(mockSql, loanID, statemachine.EventPropose, statemachine.None, constants.StatusProposed, entity.Actor{ID: borrowerID, Role: constants.RoleBorrower})
+				expectAgreementBorrower(mockSql, borrowerID)
 				mockSql.ExpectExec(regexp.QuoteMeta(
 					`UPDATE "loans"`)).
 					WithArgs(
@@ -153,16 +255,106 @@ func TestLoanUsecase_CreateLoan(t *testing.T) {
 						sqlmock.AnyArg(),
 						borrowerID,
 						principal,
+						money.IDR,
 						rate,
 						roi,
+						constants.DefaultTenor,
+						constants.DefaultFrequency,
 						constants.StatusProposed,
-						fmt.Sprintf("https://example.com/loans/%d/loan_proposal_%d.pdf", loanID, loanID),
+						"https://documents.test/1",
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
 						loanID,
 					).WillReturnError(fmt.Errorf("DB error on save link"))
 				mockSql.ExpectRollback()
 			},
-			doCleanup: true,
-			wantErr:   fmt.Errorf("failed to save loan with PDF URL"),
+			wantDiscarded: 1,
+			wantErr:       fmt.Errorf("failed to save loan with PDF URL"),
+		},
+		{
+			name: "CreateLoan_Failure_UnsupportedCurrency",
+			args: args{
+				loanRequest: entity.RequestProposeLoan{
+					Principal: principal,
+					ROI:       roi,
+					Rate:      rate,
+					Currency:  "EUR",
+				},
+				borrowerID: borrowerID,
+			},
+			wantErr: fmt.Errorf(errs.ErrUnsupportedCurrency),
+		},
+		{
+			name: "CreateLoan_Failure_InvalidMinorUnits",
+			args: args{
+				loanRequest: entity.RequestProposeLoan{
+					Principal: decimal.RequireFromString("1000.5"),
+					ROI:       roi,
+					Rate:      rate,
+					Currency:  money.JPY,
+				},
+				borrowerID: borrowerID,
+			},
+			wantErr: fmt.Errorf(errs.ErrInvalidMinorUnits),
+		},
+		{
+			name: "CreateLoan_Failure_TooManyActiveLoans",
+			args: args{
+				loanRequest: entity.RequestProposeLoan{
+					Principal: principal,
+					ROI:       roi,
+					Rate:      rate,
+				},
+				borrowerID: borrowerID,
+			},
+			policy: usecase.LoanPolicy{MaxBorrowerActiveLoans: 2},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT count(*) FROM "loans" WHERE borrower_id = $1 AND status IN ($2,$3,$4,$5,$6,$7)`)).
+					WithArgs(borrowerID, constants.StatusProposed, constants.StatusApproved, constants.StatusInvested,
+						constants.StatusDisbursed, constants.StatusRepaying, constants.StatusDefaulted).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mockSql.ExpectRollback()
+			},
+			wantErr: &errs.LimitExceededError{
+				Message: errs.ErrBorrowerActiveLoansExceeded,
+				Current: "2 active loans",
+				Limit:   "2 active loans",
+			},
+		},
+		{
+			name: "CreateLoan_Failure_OutstandingPrincipalLimit",
+			args: args{
+				loanRequest: entity.RequestProposeLoan{
+					Principal: principal,
+					ROI:       roi,
+					Rate:      rate,
+				},
+				borrowerID: borrowerID,
+			},
+			policy: usecase.LoanPolicy{MaxBorrowerOutstanding: money.Amounts{money.IDR: decimal.NewFromInt(5000)}},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT COALESCE(SUM(loans.principal), 0) FROM "loans"`)).
+					WithArgs(borrowerID, money.IDR, constants.StatusProposed, constants.StatusApproved, constants.StatusInvested,
+						constants.StatusDisbursed, constants.StatusRepaying, constants.StatusDefaulted).
+					WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(decimal.NewFromInt(6000)))
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT COALESCE(SUM(installments.paid_principal), 0) FROM "installments" JOIN loans`)).
+					WithArgs(borrowerID, money.IDR, constants.StatusProposed, constants.StatusApproved, constants.StatusInvested,
+						constants.StatusDisbursed, constants.StatusRepaying, constants.StatusDefaulted).
+					WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(decimal.NewFromInt(1500)))
+				mockSql.ExpectRollback()
+			},
+			wantErr: &errs.LimitExceededError{
+				Message: errs.ErrBorrowerOutstandingExceeded,
+				Current: "4500.00 IDR",
+				Limit:   "5000.00 IDR",
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -170,13 +362,14 @@ func TestLoanUsecase_CreateLoan(t *testing.T) {
 			db, mockSql := setupMockDB(t)
 			redis, mockRedis := redismock.NewClientMock()
 
-			u := usecase.NewLoanUsecase(db, redis)
+			documents := &fakeDocuments{}
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, documents, tt.policy)
 
 			if tt.mockFunc != nil {
 				tt.mockFunc(mockSql, mockRedis)
 			}
 
-			got, err := u.CreateLoan(tt.args.loanRequest, tt.args.borrowerID)
+			got, err := u.CreateLoan(context.Background(), tt.args.loanRequest, entity.Actor{ID: tt.args.borrowerID, Role: constants.RoleBorrower})
 			if tt.wantErr != nil {
 				assert.Error(t, err)
 				assert.Equal(t, tt.wantErr, err)
@@ -193,19 +386,12 @@ func TestLoanUsecase_CreateLoan(t *testing.T) {
 					assert.Equal(t, *tt.want.AgreementLink, *got.AgreementLink)
 				}
 			}
-			// Cleanup: remove the generated PDF file if it was created
-			if tt.doCleanup {
-				filename := fmt.Sprintf("loan_proposal_%d.pdf", loanID)
-
-				_, statErr := os.Stat(filename)
-				assert.NoError(t, statErr)
-
-				removeErr := os.Remove(filename)
-				assert.NoError(t, removeErr)
-
-				_, statErr = os.Stat(filename)
-				assert.True(t, os.IsNotExist(statErr))
+			if tt.wantDocument {
+				assertLoanAgreementDocument(t, documents, loanID, tt.args.borrowerID)
+			} else {
+				assert.Empty(t, documents.saved)
 			}
+			assert.Len(t, documents.discarded, tt.wantDiscarded)
 		})
 	}
 }
@@ -243,9 +429,10 @@ func TestLoanUsecase_RejectLoan(t *testing.T) {
 				mockSql.ExpectExec(regexp.QuoteMeta(
 					`UPDATE "loans"`)).
 					WithArgs(
-						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusRejected, sqlmock.AnyArg(), loanID,
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusRejected, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID,
 					).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventReject, constants.StatusProposed, constants.StatusRejected, entity.Actor{ID: validatorID, Role: constants.RoleValidator})
 				mockSql.ExpectQuery(regexp.QuoteMeta(
 					`INSERT INTO "loan_approvals"`)).
 					WithArgs(
@@ -255,6 +442,11 @@ func TestLoanUsecase_RejectLoan(t *testing.T) {
 						validatorID,
 						rejectReason,
 						"",
+						nil,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
 						sqlmock.AnyArg(),
 					).
 					WillReturnRows(sqlmock.NewRows([]string{"loan_id", "validator_id", "reject_reason"}).AddRow(loanID, validatorID, rejectReason))
@@ -301,7 +493,7 @@ func TestLoanUsecase_RejectLoan(t *testing.T) {
 				mockSql.ExpectExec(regexp.QuoteMeta(
 					`UPDATE "loans"`)).
 					WithArgs(
-						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusRejected, sqlmock.AnyArg(), loanID,
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusRejected, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID,
 					).
 					WillReturnError(fmt.Errorf("DB error on update loan"))
 				mockSql.ExpectRollback()
@@ -326,9 +518,10 @@ func TestLoanUsecase_RejectLoan(t *testing.T) {
 				mockSql.ExpectExec(regexp.QuoteMeta(
 					`UPDATE "loans"`)).
 					WithArgs(
-						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusRejected, sqlmock.AnyArg(), loanID,
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusRejected, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID,
 					).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventReject, constants.StatusProposed, constants.StatusRejected, entity.Actor{ID: validatorID, Role: constants.RoleValidator})
 				mockSql.ExpectQuery(regexp.QuoteMeta(
 					`INSERT INTO "loan_approvals"`)).
 					WithArgs(
@@ -338,6 +531,11 @@ func TestLoanUsecase_RejectLoan(t *testing.T) {
 						validatorID,
 						rejectReason,
 						"",
+						nil,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
 						sqlmock.AnyArg(),
 					).
 					WillReturnError(fmt.Errorf("DB error on insert approval"))
@@ -350,13 +548,13 @@ func TestLoanUsecase_RejectLoan(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			db, mockSql := setupMockDB(t)
 			redis, mockRedis := redismock.NewClientMock()
-			u := usecase.NewLoanUsecase(db, redis)
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, usecase.LoanPolicy{})
 
 			if tt.mockFunc != nil {
 				tt.mockFunc(mockSql, mockRedis)
 			}
 
-			got, err := u.RejectLoan(tt.args.rejectionRequest, tt.args.validatorID)
+			got, err := u.RejectLoan(context.Background(), tt.args.rejectionRequest, entity.Actor{ID: tt.args.validatorID, Role: constants.RoleValidator})
 			if tt.wantErr != nil {
 				assert.Error(t, err)
 				assert.Nil(t, got)
@@ -375,15 +573,27 @@ func TestLoanUsecase_ApproveLoan(t *testing.T) {
 	loanID := uint(1)
 	validatorID := uint(2)
 	agreementLink := "https://example.com/loan_agreement.pdf"
-	photoURL := "https://example.com/photo.jpg"
+	photoID := uint(4)
+	photoURL := "https://documents.test/4"
 	approvalID := uint(3)
+	minTicket := decimal.NewFromInt(500)
+	maxTicket := decimal.NewFromInt(100)
+	justification := "Collateral verified on site"
+	supervisorID := uint(9)
+	dualApproval := usecase.LoanPolicy{DualApprovalThreshold: money.Amounts{money.IDR: decimal.NewFromInt(500)}}
+	largeLoanRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "principal", "currency", "status", "agreement_link"}).
+			AddRow(loanID, decimal.NewFromInt(1000), money.IDR, constants.StatusProposed, agreementLink)
+	}
 	type args struct {
 		approvalRequest entity.RequestApproveLoan
 		validatorID     uint
+		role            constants.UserRole
 	}
 	tests := []struct {
 		name     string
 		args     args
+		policy   usecase.LoanPolicy
 		mockFunc func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock)
 		want     *entity.LoanApproval
 		wantErr  error
@@ -392,8 +602,8 @@ func TestLoanUsecase_ApproveLoan(t *testing.T) {
 			name: "ApproveLoan_Success",
 			args: args{
 				approvalRequest: entity.RequestApproveLoan{
-					LoanID:   1,
-					PhotoURL: photoURL,
+					LoanID:          1,
+					PhotoDocumentID: photoID,
 				},
 				validatorID: validatorID,
 			},
@@ -402,13 +612,16 @@ func TestLoanUsecase_ApproveLoan(t *testing.T) {
 					`SELECT * FROM "loans"`)).
 					WithArgs(1, constants.StatusProposed, 1).
 					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "agreement_link"}).AddRow(loanID, constants.StatusProposed, agreementLink))
+				expectAttachedDocument(mockSql, photoID, constants.DocumentApprovalPhoto, loanID)
+				expectBorrowerHistory(mockSql, loanID)
 				mockSql.ExpectBegin()
 				mockSql.ExpectExec(regexp.QuoteMeta(
 					`UPDATE "loans"`)).
 					WithArgs(
-						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusApproved, agreementLink, loanID,
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusApproved, agreementLink, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID,
 					).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventApprove, constants.StatusProposed, constants.StatusApproved, entity.Actor{ID: validatorID, Role: constants.RoleValidator})
 				mockSql.ExpectQuery(regexp.QuoteMeta(
 					`INSERT INTO "loan_approvals"`)).
 					WithArgs(
@@ -418,6 +631,11 @@ func TestLoanUsecase_ApproveLoan(t *testing.T) {
 						validatorID,
 						nil,
 						photoURL,
+						photoID,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
 						sqlmock.AnyArg(),
 					).
 					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "validator_id", "photo_url"}).AddRow(approvalID, loanID, validatorID, photoURL))
@@ -430,14 +648,17 @@ func TestLoanUsecase_ApproveLoan(t *testing.T) {
 				LoanID:      loanID,
 				ValidatorID: validatorID,
 				PhotoURL:    photoURL,
+				RiskGrade:   constants.RiskGradeC,
 			},
 		},
 		{
-			name: "ApproveLoan_Failure_LoanNotFound",
+			name: "ApproveLoan_Success_RiskGradeOverride",
 			args: args{
 				approvalRequest: entity.RequestApproveLoan{
-					LoanID:   1,
-					PhotoURL: photoURL,
+					LoanID:                 1,
+					PhotoDocumentID:        photoID,
+					RiskGrade:              constants.RiskGradeD,
+					RiskGradeJustification: justification,
 				},
 				validatorID: validatorID,
 			},
@@ -445,121 +666,914 @@ func TestLoanUsecase_ApproveLoan(t *testing.T) {
 				mockSql.ExpectQuery(regexp.QuoteMeta(
 					`SELECT * FROM "loans"`)).
 					WithArgs(1, constants.StatusProposed, 1).
-					WillReturnError(gorm.ErrRecordNotFound)
+					WillReturnRows(sqlmock.NewRows([]string{"id", "borrower_id", "status", "tenor", "agreement_link"}).AddRow(loanID, 5, constants.StatusProposed, 12, agreementLink))
+				expectAttachedDocument(mockSql, photoID, constants.DocumentApprovalPhoto, loanID)
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT status, COUNT(*) AS count FROM "loans"`)).
+					WithArgs(5, loanID).
+					WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+						AddRow(constants.StatusPaidOff, 1).
+						AddRow(constants.StatusRejected, 1))
+				mockSql.ExpectBegin()
+				mockSql.ExpectExec(regexp.QuoteMeta(
+					`UPDATE "loans"`)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventApprove, constants.StatusProposed, constants.StatusApproved, entity.Actor{ID: validatorID, Role: constants.RoleValidator})
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`INSERT INTO "loan_approvals"`)).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						loanID,
+						validatorID,
+						nil,
+						photoURL,
+						photoID,
+						sqlmock.AnyArg(),
+						65,
+						constants.RiskGradeB,
+						constants.RiskGradeD,
+						justification,
+					).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(approvalID))
+				mockSql.ExpectCommit()
+			},
+			want: &entity.LoanApproval{
+				DBCommon: entity.DBCommon{
+					ID: approvalID,
+				},
+				LoanID:      loanID,
+				ValidatorID: validatorID,
+				PhotoURL:    photoURL,
+				RiskGrade:   constants.RiskGradeD,
 			},
-			wantErr: gorm.ErrRecordNotFound,
 		},
 		{
-			name: "ApproveLoan_Failure_DBError_UpdateLoan",
+			name: "ApproveLoan_Pending_FirstSignoffOfLargeLoan",
 			args: args{
 				approvalRequest: entity.RequestApproveLoan{
-					LoanID:   1,
-					PhotoURL: photoURL,
+					LoanID:          1,
+					PhotoDocumentID: photoID,
 				},
 				validatorID: validatorID,
 			},
+			policy: dualApproval,
 			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
 				mockSql.ExpectQuery(regexp.QuoteMeta(
 					`SELECT * FROM "loans"`)).
 					WithArgs(1, constants.StatusProposed, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "agreement_link"}).AddRow(loanID, constants.StatusProposed, agreementLink))
+					WillReturnRows(largeLoanRows())
+				expectAttachedDocument(mockSql, photoID, constants.DocumentApprovalPhoto, loanID)
+				expectBorrowerHistory(mockSql, loanID)
 				mockSql.ExpectBegin()
-				mockSql.ExpectExec(regexp.QuoteMeta(
-					`UPDATE "loans"`)).
-					WithArgs(
-						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusApproved, agreementLink, loanID,
-					).
-					WillReturnError(fmt.Errorf("DB error on update loan"))
-				mockSql.ExpectRollback()
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "approval_signoffs" WHERE loan_id = $1`)).
+					WithArgs(loanID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "approver_id", "role"}))
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`INSERT INTO "approval_signoffs"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), loanID, validatorID, constants.RoleValidator, photoID, constants.RiskGradeC, nil, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mockSql.ExpectCommit()
+			},
+			want: &entity.LoanApproval{
+				LoanID:      loanID,
+				ValidatorID: validatorID,
+				PhotoURL:    photoURL,
+				RiskGrade:   constants.RiskGradeC,
+				Pending:     true,
+				Signoffs:    []entity.ApprovalSignoff{{}},
 			},
-			wantErr: fmt.Errorf("DB error on update loan"),
 		},
 		{
-			name: "ApproveLoan_Failure_DBError_InsertApproval",
+			name: "ApproveLoan_Success_ValidatorAfterSupervisor",
 			args: args{
 				approvalRequest: entity.RequestApproveLoan{
-					LoanID:   1,
-					PhotoURL: photoURL,
+					LoanID:          1,
+					PhotoDocumentID: photoID,
 				},
 				validatorID: validatorID,
 			},
+			policy: dualApproval,
 			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
 				mockSql.ExpectQuery(regexp.QuoteMeta(
 					`SELECT * FROM "loans"`)).
 					WithArgs(1, constants.StatusProposed, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "agreement_link"}).AddRow(loanID, constants.StatusProposed, agreementLink))
+					WillReturnRows(largeLoanRows())
+				expectAttachedDocument(mockSql, photoID, constants.DocumentApprovalPhoto, loanID)
+				expectBorrowerHistory(mockSql, loanID)
 				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "approval_signoffs" WHERE loan_id = $1`)).
+					WithArgs(loanID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "approver_id", "role", "risk_grade"}).
+						AddRow(1, loanID, supervisorID, constants.RoleSupervisor, constants.RiskGradeC))
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`INSERT INTO "approval_signoffs"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), loanID, validatorID, constants.RoleValidator, photoID, constants.RiskGradeC, nil, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
 				mockSql.ExpectExec(regexp.QuoteMeta(
 					`UPDATE "loans"`)).
-					WithArgs(
-						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusApproved, agreementLink, loanID,
-					).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventApprove, constants.StatusProposed, constants.StatusApproved, entity.Actor{ID: validatorID, Role: constants.RoleValidator})
 				mockSql.ExpectQuery(regexp.QuoteMeta(
 					`INSERT INTO "loan_approvals"`)).
-					WithArgs(
-						sqlmock.AnyArg(),
-						sqlmock.AnyArg(),
-						loanID,
-						validatorID,
-						nil,
-						photoURL,
-						sqlmock.AnyArg(),
-					).
-					WillReturnError(fmt.Errorf("DB error on insert approval"))
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(approvalID))
+				mockSql.ExpectCommit()
+			},
+			want: &entity.LoanApproval{
+				DBCommon: entity.DBCommon{
+					ID: approvalID,
+				},
+				LoanID:      loanID,
+				ValidatorID: validatorID,
+				PhotoURL:    photoURL,
+				RiskGrade:   constants.RiskGradeC,
+				Signoffs:    []entity.ApprovalSignoff{{}, {}},
+			},
+		},
+		{
+			name: "ApproveLoan_Pending_SecondSupervisorIsNotEnough",
+			args: args{
+				approvalRequest: entity.RequestApproveLoan{
+					LoanID:          1,
+					PhotoDocumentID: photoID,
+				},
+				validatorID: supervisorID + 1,
+				role:        constants.RoleSupervisor,
+			},
+			policy: dualApproval,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs(1, constants.StatusProposed, 1).
+					WillReturnRows(largeLoanRows())
+				expectAttachedDocument(mockSql, photoID, constants.DocumentApprovalPhoto, loanID)
+				expectBorrowerHistory(mockSql, loanID)
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "approval_signoffs" WHERE loan_id = $1`)).
+					WithArgs(loanID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "approver_id", "role", "risk_grade"}).
+						AddRow(1, loanID, supervisorID, constants.RoleSupervisor, constants.RiskGradeC))
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`INSERT INTO "approval_signoffs"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), loanID, supervisorID+1, constants.RoleSupervisor, photoID, constants.RiskGradeC, nil, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mockSql.ExpectCommit()
+			},
+			want: &entity.LoanApproval{
+				LoanID:      loanID,
+				ValidatorID: supervisorID + 1,
+				PhotoURL:    photoURL,
+				RiskGrade:   constants.RiskGradeC,
+				Pending:     true,
+				Signoffs:    []entity.ApprovalSignoff{{}, {}},
+			},
+		},
+		{
+			name: "ApproveLoan_Failure_AlreadySignedOff",
+			args: args{
+				approvalRequest: entity.RequestApproveLoan{
+					LoanID:          1,
+					PhotoDocumentID: photoID,
+				},
+				validatorID: validatorID,
+			},
+			policy: dualApproval,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs(1, constants.StatusProposed, 1).
+					WillReturnRows(largeLoanRows())
+				expectAttachedDocument(mockSql, photoID, constants.DocumentApprovalPhoto, loanID)
+				expectBorrowerHistory(mockSql, loanID)
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "approval_signoffs" WHERE loan_id = $1`)).
+					WithArgs(loanID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "approver_id", "role", "risk_grade"}).
+						AddRow(1, loanID, validatorID, constants.RoleValidator, constants.RiskGradeC))
 				mockSql.ExpectRollback()
 			},
-			wantErr: fmt.Errorf("DB error on insert approval"),
+			wantErr: fmt.Errorf(errs.ErrAlreadySignedOff),
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			db, mockSql := setupMockDB(t)
-			redis, mockRedis := redismock.NewClientMock()
-			u := usecase.NewLoanUsecase(db, redis)
-			if tt.mockFunc != nil {
-				tt.mockFunc(mockSql, mockRedis)
-			}
-			got, err := u.ApproveLoan(tt.args.approvalRequest, tt.args.validatorID)
-			if tt.wantErr != nil {
-				assert.Error(t, err)
-				assert.Nil(t, got)
-			} else {
-				assert.NoError(t, err)
-				assert.NotNil(t, got)
-				assert.Equal(t, tt.want.LoanID, got.LoanID)
-				assert.Equal(t, tt.want.ValidatorID, got.ValidatorID)
-				assert.Equal(t, tt.want.PhotoURL, got.PhotoURL)
-				assert.Equal(t, tt.want.ID, got.ID)
-			}
-		})
-	}
-}
-
-func TestLoanUsecase_AddInvestment(t *testing.T) {
-	loanID := uint(1)
-	investorID := uint(2)
-	principal := 1000.0
-	amount := 500.0
-	type args struct {
-		ctx               context.Context
-		investmentRequest entity.RequestAddInvestment
-		investorID        uint
-	}
-	tests := []struct {
-		name     string
-		args     args
-		mockFunc func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock)
-		want     *entity.Investment
-		wantErr  error
-	}{
 		{
-			name: "successfully add investment to match principal and change loan status to invested",
+			name: "ApproveLoan_Failure_SupervisorAloneBelowThreshold",
 			args: args{
-				ctx: context.Background(),
-				investmentRequest: entity.RequestAddInvestment{
-					LoanID: loanID,
-					Amount: amount,
+				approvalRequest: entity.RequestApproveLoan{
+					LoanID:          1,
+					PhotoDocumentID: photoID,
+				},
+				validatorID: supervisorID,
+				role:        constants.RoleSupervisor,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs(1, constants.StatusProposed, 1).
+					WillReturnRows(largeLoanRows())
+				expectAttachedDocument(mockSql, photoID, constants.DocumentApprovalPhoto, loanID)
+				expectBorrowerHistory(mockSql, loanID)
+				mockSql.ExpectBegin()
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf(errs.ErrUnauthorizedAction),
+		},
+		{
+			name: "ApproveLoan_Failure_SignoffWithAnotherRiskGrade",
+			args: args{
+				approvalRequest: entity.RequestApproveLoan{
+					LoanID:          1,
+					PhotoDocumentID: photoID,
+				},
+				validatorID: validatorID,
+			},
+			policy: dualApproval,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs(1, constants.StatusProposed, 1).
+					WillReturnRows(largeLoanRows())
+				expectAttachedDocument(mockSql, photoID, constants.DocumentApprovalPhoto, loanID)
+				expectBorrowerHistory(mockSql, loanID)
+				mockSql.ExpectBegin()
+				// the supervisor overrode the grade, the validator keeps the scored one
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "approval_signoffs" WHERE loan_id = $1`)).
+					WithArgs(loanID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "approver_id", "role", "risk_grade", "risk_grade_justification"}).
+						AddRow(1, loanID, supervisorID, constants.RoleSupervisor, constants.RiskGradeD, justification))
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf(errs.ErrRiskGradeMismatch),
+		},
+		{
+			name: "ApproveLoan_Failure_SignoffWithOtherTicketSizes",
+			args: args{
+				approvalRequest: entity.RequestApproveLoan{
+					LoanID:          1,
+					PhotoDocumentID: photoID,
+				},
+				validatorID: validatorID,
+			},
+			policy: dualApproval,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs(1, constants.StatusProposed, 1).
+					WillReturnRows(largeLoanRows())
+				expectAttachedDocument(mockSql, photoID, constants.DocumentApprovalPhoto, loanID)
+				expectBorrowerHistory(mockSql, loanID)
+				mockSql.ExpectBegin()
+				// the supervisor set a minimum ticket, the validator keeps the default ticket sizes
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "approval_signoffs" WHERE loan_id = $1`)).
+					WithArgs(loanID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "approver_id", "role", "risk_grade", "min_ticket"}).
+						AddRow(1, loanID, supervisorID, constants.RoleSupervisor, constants.RiskGradeC, decimal.NewFromInt(200)))
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf(errs.ErrTicketSizeMismatch),
+		},
+		{
+			name: "ApproveLoan_Failure_InvalidRiskGrade",
+			args: args{
+				approvalRequest: entity.RequestApproveLoan{
+					LoanID:                 1,
+					PhotoDocumentID:        photoID,
+					RiskGrade:              "F",
+					RiskGradeJustification: justification,
+				},
+				validatorID: validatorID,
+			},
+			wantErr: fmt.Errorf(errs.ErrInvalidRiskGrade),
+		},
+		{
+			name: "ApproveLoan_Failure_OverrideWithoutJustification",
+			args: args{
+				approvalRequest: entity.RequestApproveLoan{
+					LoanID:          1,
+					PhotoDocumentID: photoID,
+					RiskGrade:       constants.RiskGradeA,
+				},
+				validatorID: validatorID,
+			},
+			wantErr: fmt.Errorf(errs.ErrRiskGradeJustificationRequired),
+		},
+		{
+			name: "ApproveLoan_Failure_LoanNotFound",
+			args: args{
+				approvalRequest: entity.RequestApproveLoan{
+					LoanID:          1,
+					PhotoDocumentID: photoID,
+				},
+				validatorID: validatorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs(1, constants.StatusProposed, 1).
+					WillReturnError(gorm.ErrRecordNotFound)
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "ApproveLoan_Failure_PhotoNotAttached",
+			args: args{
+				approvalRequest: entity.RequestApproveLoan{
+					LoanID:          1,
+					PhotoDocumentID: photoID,
+				},
+				validatorID: validatorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs(1, constants.StatusProposed, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "agreement_link"}).AddRow(loanID, constants.StatusProposed, agreementLink))
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "documents" WHERE id = $1 AND kind = $2 AND loan_id = $3`)).
+					WithArgs(photoID, constants.DocumentApprovalPhoto, loanID, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: fmt.Errorf(errs.ErrDocumentNotAttached),
+		},
+		{
+			name: "ApproveLoan_Failure_DBError_UpdateLoan",
+			args: args{
+				approvalRequest: entity.RequestApproveLoan{
+					LoanID:          1,
+					PhotoDocumentID: photoID,
+				},
+				validatorID: validatorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs(1, constants.StatusProposed, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "agreement_link"}).AddRow(loanID, constants.StatusProposed, agreementLink))
+				expectAttachedDocument(mockSql, photoID, constants.DocumentApprovalPhoto, loanID)
+				expectBorrowerHistory(mockSql, loanID)
+				mockSql.ExpectBegin()
+				mockSql.ExpectExec(regexp.QuoteMeta(
+					`UPDATE "loans"`)).
+					WithArgs(
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusApproved, agreementLink, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID,
+					).
+					WillReturnError(fmt.Errorf("DB error on update loan"))
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf("DB error on update loan"),
+		},
+		{
+			name: "ApproveLoan_Failure_DBError_InsertApproval",
+			args: args{
+				approvalRequest: entity.RequestApproveLoan{
+					LoanID:          1,
+					PhotoDocumentID: photoID,
+				},
+				validatorID: validatorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs(1, constants.StatusProposed, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "agreement_link"}).AddRow(loanID, constants.StatusProposed, agreementLink))
+				expectAttachedDocument(mockSql, photoID, constants.DocumentApprovalPhoto, loanID)
+				expectBorrowerHistory(mockSql, loanID)
+				mockSql.ExpectBegin()
+				mockSql.ExpectExec(regexp.QuoteMeta(
+					`UPDATE "loans"`)).
+					WithArgs(
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusApproved, agreementLink, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID,
+					).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventApprove, constants.StatusProposed, constants.StatusApproved, entity.Actor{ID: validatorID, Role: constants.RoleValidator})
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`INSERT INTO "loan_approvals"`)).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						loanID,
+						validatorID,
+						nil,
+						photoURL,
+						photoID,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+					).
+					WillReturnError(fmt.Errorf("DB error on insert approval"))
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf("DB error on insert approval"),
+		},
+		{
+			name: "ApproveLoan_Failure_InvalidTicketSize",
+			args: args{
+				approvalRequest: entity.RequestApproveLoan{
+					LoanID:          1,
+					PhotoDocumentID: photoID,
+					MinTicket:       &minTicket,
+					MaxTicket:       &maxTicket,
+				},
+				validatorID: validatorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs(1, constants.StatusProposed, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).AddRow(loanID, decimal.NewFromInt(1000), money.IDR, constants.StatusProposed))
+			},
+			wantErr: fmt.Errorf(errs.ErrInvalidTicketSize),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			redis, mockRedis := redismock.NewClientMock()
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, tt.policy)
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql, mockRedis)
+			}
+			role := tt.args.role
+			if role == "" {
+				role = constants.RoleValidator
+			}
+			got, err := u.ApproveLoan(context.Background(), tt.args.approvalRequest, entity.Actor{ID: tt.args.validatorID, Role: role})
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+				assert.Equal(t, tt.want.LoanID, got.LoanID)
+				assert.Equal(t, tt.want.ValidatorID, got.ValidatorID)
+				assert.Equal(t, tt.want.PhotoURL, got.PhotoURL)
+				assert.Equal(t, tt.want.ID, got.ID)
+				assert.Equal(t, tt.want.RiskGrade, got.RiskGrade)
+				assert.Equal(t, tt.want.Pending, got.Pending)
+				assert.Equal(t, len(tt.want.Signoffs), len(got.Signoffs))
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLoanUsecase_AddInvestment(t *testing.T) {
+	loanID := uint(1)
+	investorID := uint(2)
+	principal := decimal.NewFromInt(1000)
+	amount := decimal.NewFromInt(500)
+	// expectInvestmentAgreements expects the agreements of the two investments filling the loan to be written
+	expectInvestmentAgreements := func(mockSql sqlmock.Sqlmock) {
+		mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "investments" WHERE loan_id = $1 AND status = $2 ORDER BY id`)).
+			WithArgs(loanID, constants.InvestmentActive).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "investor_id", "amount", "currency", "status"}).
+				AddRow(1, loanID, investorID, principal.Sub(amount), money.IDR, constants.InvestmentActive).
+				AddRow(2, loanID, investorID, amount, money.IDR, constants.InvestmentActive))
+		mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id IN ($1)`)).
+			WithArgs(investorID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email"}).AddRow(investorID, "investor", "investor@example.com"))
+		for i, investmentID := range []uint{1, 2} {
+			mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "investments" SET "agreement_link"=$1,"agreement_version"=$2,"updated_at"=$3 WHERE "id" = $4`)).
+				WithArgs(fmt.Sprintf("https://documents.test/%d", i+1), agreement.CurrentInvestmentVersion, sqlmock.AnyArg(), investmentID).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+	}
+
+	type args struct {
+		ctx               context.Context
+		investmentRequest entity.RequestAddInvestment
+		investorID        uint
+	}
+	tests := []struct {
+		name         string
+		args         args
+		policy       usecase.LoanPolicy
+		mockFunc     func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock)
+		want         *entity.Investment
+		notifyErr    error
+		wantErr      error
+		wantNotified []uint
+		// wantAgreements are the investments an agreement document is saved for
+		wantAgreements []uint
+	}{
+		{
+			name: "successfully add investment to match principal and change loan status to invested",
+			args: args{
+				ctx: context.Background(),
+				investmentRequest: entity.RequestAddInvestment{
+					LoanID: loanID,
+					Amount: amount,
+				},
+				investorID: investorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				lockKey := fmt.Sprintf("event_lock:%d", loanID)
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
+					WithArgs(loanID, constants.StatusApproved, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).
+						AddRow(loanID, principal, money.IDR, constants.StatusApproved))
+
+				// Mock loan.Investments preload (empty in this test)
+				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
+					WithArgs(loanID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "currency", "investor_id", "status"}).
+						AddRow(1, loanID, principal.Sub(amount), money.IDR, investorID, constants.InvestmentActive))
+
+				mockSql.ExpectQuery(`INSERT INTO "investments"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						loanID,
+						investorID,
+						amount,
+						money.IDR,
+						constants.InvestmentActive,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+					).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+				mockSql.ExpectExec(`UPDATE "loans"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						principal,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						constants.StatusInvested,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						loanID,
+					).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mockSql.ExpectQuery(`INSERT INTO "investments"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						loanID,
+						investorID,
+						amount,
+						money.IDR,
+						constants.InvestmentActive,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						1,
+					).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				expectInvestmentAgreements(mockSql)
+				expectLoanEvent(mockSql, loanID, statemachine.EventInvest, constants.StatusApproved, constants.StatusInvested, entity.Actor{ID: investorID, Role: constants.RoleInvestor})
+
+				mockSql.ExpectCommit()
+			},
+			want: &entity.Investment{
+				LoanID:     loanID,
+				InvestorID: investorID,
+				Amount:     amount,
+				Status:     constants.InvestmentActive,
+			},
+			wantErr:        nil,
+			wantNotified:   []uint{loanID},
+			wantAgreements: []uint{1, 2},
+		},
+		{
+			name: "investment is rolled back when the investor notifications can not be queued",
+			args: args{
+				ctx: context.Background(),
+				investmentRequest: entity.RequestAddInvestment{
+					LoanID: loanID,
+					Amount: amount,
+				},
+				investorID: investorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				lockKey := fmt.Sprintf("event_lock:%d", loanID)
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
+					WithArgs(loanID, constants.StatusApproved, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).
+						AddRow(loanID, principal, money.IDR, constants.StatusApproved))
+
+				// Mock loan.Investments preload (empty in this test)
+				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
+					WithArgs(loanID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "currency", "investor_id", "status"}).
+						AddRow(1, loanID, principal.Sub(amount), money.IDR, investorID, constants.InvestmentActive))
+
+				mockSql.ExpectQuery(`INSERT INTO "investments"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						loanID,
+						investorID,
+						amount,
+						money.IDR,
+						constants.InvestmentActive,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+					).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+				mockSql.ExpectExec(`UPDATE "loans"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						principal,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						constants.StatusInvested,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						loanID,
+					).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				mockSql.ExpectQuery(`INSERT INTO "investments"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						loanID,
+						investorID,
+						amount,
+						money.IDR,
+						constants.InvestmentActive,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						1,
+					).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				expectInvestmentAgreements(mockSql)
+				mockSql.ExpectRollback()
+			},
+			notifyErr:      fmt.Errorf("DB error"),
+			wantErr:        fmt.Errorf("DB error"),
+			wantAgreements: []uint{1, 2},
+		},
+		{
+			name: "add investment below principal but not enough to change loan status",
+			args: args{
+				ctx: context.Background(),
+				investmentRequest: entity.RequestAddInvestment{
+					LoanID: loanID,
+					Amount: amount,
+				},
+				investorID: investorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				lockKey := fmt.Sprintf("event_lock:%d", loanID)
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
+					WithArgs(loanID, constants.StatusApproved, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).
+						AddRow(loanID, principal, money.IDR, constants.StatusApproved))
+
+				// Mock loan.Investments preload (empty in this test)
+				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
+					WithArgs(loanID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}))
+
+				mockSql.ExpectQuery(`INSERT INTO "investments"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						loanID,
+						investorID,
+						amount,
+						money.IDR,
+						constants.InvestmentActive,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+					).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+				mockSql.ExpectCommit()
+			},
+			want: &entity.Investment{
+				LoanID:     loanID,
+				InvestorID: investorID,
+				Amount:     amount,
+				Status:     constants.InvestmentActive,
+			},
+		},
+		{
+			name: "investment is retried when a concurrent transaction aborts the serializable commit",
+			args: args{
+				ctx: context.Background(),
+				investmentRequest: entity.RequestAddInvestment{
+					LoanID: loanID,
+					Amount: amount,
+				},
+				investorID: investorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				lockKey := fmt.Sprintf("event_lock:%d", loanID)
+				for _, commitErr := range []error{&pgconn.PgError{Code: "40001"}, nil} {
+					mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+					mockRedis.ExpectDel(lockKey).SetVal(1)
+
+					mockSql.ExpectBegin()
+					mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
+						WithArgs(loanID, constants.StatusApproved, 1).
+						WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).
+							AddRow(loanID, principal, money.IDR, constants.StatusApproved))
+					mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
+						WithArgs(loanID, constants.InvestmentActive).
+						WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}))
+					mockSql.ExpectQuery(`INSERT INTO "investments"`).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+					mockSql.ExpectCommit().WillReturnError(commitErr)
+				}
+			},
+			want: &entity.Investment{
+				LoanID:     loanID,
+				InvestorID: investorID,
+				Amount:     amount,
+				Status:     constants.InvestmentActive,
+			},
+		},
+		{
+			name: "failure due to concurrent transactions aborting every attempt",
+			args: args{
+				ctx: context.Background(),
+				investmentRequest: entity.RequestAddInvestment{
+					LoanID: loanID,
+					Amount: amount,
+				},
+				investorID: investorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				lockKey := fmt.Sprintf("event_lock:%d", loanID)
+				for attempt := 0; attempt < constants.MaxSerializableAttempts; attempt++ {
+					mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+					mockRedis.ExpectDel(lockKey).SetVal(1)
+
+					mockSql.ExpectBegin()
+					mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
+						WithArgs(loanID, constants.StatusApproved, 1).
+						WillReturnError(&pgconn.PgError{Code: "40001"})
+					mockSql.ExpectRollback()
+				}
+			},
+			wantErr: fmt.Errorf(errs.ErrConcurrentUpdate),
+		},
+		{
+			name: "failure due to investment exceeding principal",
+			args: args{
+				ctx: context.Background(),
+				investmentRequest: entity.RequestAddInvestment{
+					LoanID: loanID,
+					Amount: principal.Add(decimal.NewFromInt(100)),
+				},
+				investorID: investorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				lockKey := fmt.Sprintf("event_lock:%d", loanID)
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
+					WithArgs(loanID, constants.StatusApproved, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).
+						AddRow(loanID, principal, money.IDR, constants.StatusApproved))
+
+				// Mock loan.Investments preload (empty in this test)
+				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
+					WithArgs(loanID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}))
+
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf(errs.ErrInvestmentExceedsPrincipal),
+		},
+		{
+			name: "failure due to lock acquisition error",
+			args: args{
+				ctx: context.Background(),
+				investmentRequest: entity.RequestAddInvestment{
+					LoanID: loanID,
+					Amount: amount,
+				},
+				investorID: investorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				lockKey := fmt.Sprintf("event_lock:%d", loanID)
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetErr(assert.AnError)
+			},
+			wantErr: fmt.Errorf(errs.ErrLockAcquisitionFailed),
+		},
+		{
+			name: "failure due to failed to acquire lock",
+			args: args{
+				ctx: context.Background(),
+				investmentRequest: entity.RequestAddInvestment{
+					LoanID: loanID,
+					Amount: amount,
+				},
+				investorID: investorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				lockKey := fmt.Sprintf("event_lock:%d", loanID)
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(false)
+			},
+			wantErr: fmt.Errorf(errs.ErrBusySystem),
+		},
+		{
+			name: "failure due to DB error on getting loan",
+			args: args{
+				ctx: context.Background(),
+				investmentRequest: entity.RequestAddInvestment{
+					LoanID: loanID,
+					Amount: amount,
+				},
+				investorID: investorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				lockKey := fmt.Sprintf("event_lock:%d", loanID)
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
+					WithArgs(loanID, constants.StatusApproved, 1).
+					WillReturnError(fmt.Errorf("DB error on getting loan"))
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf("DB error on getting loan"),
+		},
+		{
+			name: "failure due to DB error on creating investment",
+			args: args{
+				ctx: context.Background(),
+				investmentRequest: entity.RequestAddInvestment{
+					LoanID: loanID,
+					Amount: amount,
+				},
+				investorID: investorID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				lockKey := fmt.Sprintf("event_lock:%d", loanID)
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
+					WithArgs(loanID, constants.StatusApproved, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).
+						AddRow(loanID, principal, money.IDR, constants.StatusApproved))
+
+				// Mock loan.Investments preload (empty in this test)
+				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
+					WithArgs(loanID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}))
+
+				mockSql.ExpectQuery(`INSERT INTO "investments"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						loanID,
+						investorID,
+						amount,
+						money.IDR,
+						constants.InvestmentActive,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+					).WillReturnError(fmt.Errorf("DB error on creating investment"))
+
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf("DB error on creating investment"),
+		},
+		{
+			name: "failure due to DB error on updating loan status",
+			args: args{
+				ctx: context.Background(),
+				investmentRequest: entity.RequestAddInvestment{
+					LoanID: loanID,
+					Amount: principal,
 				},
 				investorID: investorID,
 			},
@@ -571,14 +1585,13 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 				mockSql.ExpectBegin()
 				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
 					WithArgs(loanID, constants.StatusApproved, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "status"}).
-						AddRow(loanID, principal, constants.StatusApproved))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).
+						AddRow(loanID, principal, money.IDR, constants.StatusApproved))
 
 				// Mock loan.Investments preload (empty in this test)
 				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
-					WithArgs(loanID).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}).
-						AddRow(1, loanID, principal-amount, investorID))
+					WithArgs(loanID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}))
 
 				mockSql.ExpectQuery(`INSERT INTO "investments"`).
 					WithArgs(
@@ -586,8 +1599,12 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 						sqlmock.AnyArg(),
 						loanID,
 						investorID,
-						amount,
-					).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+						principal,
+						money.IDR,
+						constants.InvestmentActive,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+					).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 				mockSql.ExpectExec(`UPDATE "loans"`).
 					WithArgs(
@@ -597,33 +1614,25 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 						principal,
 						sqlmock.AnyArg(),
 						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
 						constants.StatusInvested,
 						sqlmock.AnyArg(),
-						loanID,
-					).
-					WillReturnResult(sqlmock.NewResult(1, 1))
-
-				mockSql.ExpectQuery(`INSERT INTO "investments"`).
-					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
 						sqlmock.AnyArg(),
 						sqlmock.AnyArg(),
 						loanID,
-						investorID,
-						amount,
-						1,
-					).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+					).
+					WillReturnError(fmt.Errorf("DB error on updating loan status"))
 
-				mockSql.ExpectCommit()
-			},
-			want: &entity.Investment{
-				LoanID:     loanID,
-				InvestorID: investorID,
-				Amount:     amount,
+				mockSql.ExpectRollback()
 			},
-			wantErr: nil,
+			wantErr: fmt.Errorf("DB error on updating loan status"),
 		},
 		{
-			name: "add investment below principal but not enough to change loan status",
+			name: "failure due to investment currency not matching the loan",
 			args: args{
 				ctx: context.Background(),
 				investmentRequest: entity.RequestAddInvestment{
@@ -640,38 +1649,23 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 				mockSql.ExpectBegin()
 				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
 					WithArgs(loanID, constants.StatusApproved, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "status"}).
-						AddRow(loanID, principal, constants.StatusApproved))
-
-				// Mock loan.Investments preload (empty in this test)
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).
+						AddRow(loanID, principal, money.SGD, constants.StatusApproved))
 				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
-					WithArgs(loanID).
+					WithArgs(loanID, constants.InvestmentActive).
 					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}))
-
-				mockSql.ExpectQuery(`INSERT INTO "investments"`).
-					WithArgs(
-						sqlmock.AnyArg(),
-						sqlmock.AnyArg(),
-						loanID,
-						investorID,
-						amount,
-					).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
-
-				mockSql.ExpectCommit()
-			},
-			want: &entity.Investment{
-				LoanID:     loanID,
-				InvestorID: investorID,
-				Amount:     amount,
+				mockSql.ExpectRollback()
 			},
+			wantErr: fmt.Errorf(errs.ErrCurrencyMismatch),
 		},
 		{
-			name: "failure due to investment exceeding principal",
+			name: "failure due to amount finer than the currency minor units",
 			args: args{
 				ctx: context.Background(),
 				investmentRequest: entity.RequestAddInvestment{
-					LoanID: loanID,
-					Amount: principal + 100,
+					LoanID:   loanID,
+					Amount:   decimal.RequireFromString("100.005"),
+					Currency: money.IDR,
 				},
 				investorID: investorID,
 			},
@@ -683,20 +1677,17 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 				mockSql.ExpectBegin()
 				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
 					WithArgs(loanID, constants.StatusApproved, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "status"}).
-						AddRow(loanID, principal, constants.StatusApproved))
-
-				// Mock loan.Investments preload (empty in this test)
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).
+						AddRow(loanID, principal, money.IDR, constants.StatusApproved))
 				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
-					WithArgs(loanID).
+					WithArgs(loanID, constants.InvestmentActive).
 					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}))
-
 				mockSql.ExpectRollback()
 			},
-			wantErr: fmt.Errorf(errs.ErrInvestmentExceedsPrincipal),
+			wantErr: fmt.Errorf(errs.ErrInvalidMinorUnits),
 		},
 		{
-			name: "failure due to lock acquisition error",
+			name: "failure due to amount below the minimum ticket size",
 			args: args{
 				ctx: context.Background(),
 				investmentRequest: entity.RequestAddInvestment{
@@ -705,14 +1696,26 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 				},
 				investorID: investorID,
 			},
+			policy: usecase.LoanPolicy{MinTicket: money.Amounts{money.IDR: decimal.NewFromInt(600)}},
 			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
 				lockKey := fmt.Sprintf("event_lock:%d", loanID)
-				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetErr(assert.AnError)
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
+					WithArgs(loanID, constants.StatusApproved, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).
+						AddRow(loanID, principal, money.IDR, constants.StatusApproved))
+				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
+					WithArgs(loanID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}))
+				mockSql.ExpectRollback()
 			},
-			wantErr: fmt.Errorf(errs.ErrLockAcquisitionFailed),
+			wantErr: fmt.Errorf(errs.ErrInvestmentBelowMinTicket),
 		},
 		{
-			name: "failure due to failed to acquire lock",
+			name: "failure due to amount above the maximum ticket size of the loan",
 			args: args{
 				ctx: context.Background(),
 				investmentRequest: entity.RequestAddInvestment{
@@ -721,14 +1724,26 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 				},
 				investorID: investorID,
 			},
+			policy: usecase.LoanPolicy{MaxTicket: money.Amounts{money.IDR: decimal.NewFromInt(1000)}},
 			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
 				lockKey := fmt.Sprintf("event_lock:%d", loanID)
-				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(false)
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
+					WithArgs(loanID, constants.StatusApproved, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status", "max_ticket"}).
+						AddRow(loanID, principal, money.IDR, constants.StatusApproved, decimal.NewFromInt(400)))
+				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
+					WithArgs(loanID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}))
+				mockSql.ExpectRollback()
 			},
-			wantErr: fmt.Errorf(errs.ErrBusySystem),
+			wantErr: fmt.Errorf(errs.ErrInvestmentAboveMaxTicket),
 		},
 		{
-			name: "failure due to DB error on getting loan",
+			name: "last investment below the minimum ticket size fills the loan",
 			args: args{
 				ctx: context.Background(),
 				investmentRequest: entity.RequestAddInvestment{
@@ -737,6 +1752,7 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 				},
 				investorID: investorID,
 			},
+			policy: usecase.LoanPolicy{MinTicket: money.Amounts{money.IDR: decimal.NewFromInt(600)}},
 			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
 				lockKey := fmt.Sprintf("event_lock:%d", loanID)
 				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
@@ -745,21 +1761,55 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 				mockSql.ExpectBegin()
 				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
 					WithArgs(loanID, constants.StatusApproved, 1).
-					WillReturnError(fmt.Errorf("DB error on getting loan"))
-				mockSql.ExpectRollback()
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).
+						AddRow(loanID, principal, money.IDR, constants.StatusApproved))
+				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
+					WithArgs(loanID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "currency", "investor_id", "status"}).
+						AddRow(1, loanID, principal.Sub(amount), money.IDR, investorID, constants.InvestmentActive))
+
+				mockSql.ExpectQuery(`INSERT INTO "investments"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						loanID,
+						investorID,
+						amount,
+						money.IDR,
+						constants.InvestmentActive,
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+					).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+				mockSql.ExpectExec(`UPDATE "loans"`).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mockSql.ExpectQuery(`INSERT INTO "investments"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				expectInvestmentAgreements(mockSql)
+				expectLoanEvent(mockSql, loanID, statemachine.EventInvest, constants.StatusApproved, constants.StatusInvested, entity.Actor{ID: investorID, Role: constants.RoleInvestor})
+
+				mockSql.ExpectCommit()
 			},
-			wantErr: fmt.Errorf("DB error on getting loan"),
+			want: &entity.Investment{
+				LoanID:     loanID,
+				InvestorID: investorID,
+				Amount:     amount,
+				Status:     constants.InvestmentActive,
+			},
+			wantNotified:   []uint{loanID},
+			wantAgreements: []uint{1, 2},
 		},
 		{
-			name: "failure due to DB error on creating investment",
+			name: "failure due to investor holding too much of the loan",
 			args: args{
 				ctx: context.Background(),
 				investmentRequest: entity.RequestAddInvestment{
 					LoanID: loanID,
-					Amount: amount,
+					Amount: decimal.NewFromInt(300),
 				},
 				investorID: investorID,
 			},
+			policy: usecase.LoanPolicy{MaxInvestorLoanShare: decimal.NewFromInt(50)},
 			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
 				lockKey := fmt.Sprintf("event_lock:%d", loanID)
 				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
@@ -768,37 +1818,32 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 				mockSql.ExpectBegin()
 				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
 					WithArgs(loanID, constants.StatusApproved, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "status"}).
-						AddRow(loanID, principal, constants.StatusApproved))
-
-				// Mock loan.Investments preload (empty in this test)
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "status"}).
+						AddRow(loanID, principal, money.IDR, constants.StatusApproved))
 				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
-					WithArgs(loanID).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}))
-
-				mockSql.ExpectQuery(`INSERT INTO "investments"`).
-					WithArgs(
-						sqlmock.AnyArg(),
-						sqlmock.AnyArg(),
-						loanID,
-						investorID,
-						amount,
-					).WillReturnError(fmt.Errorf("DB error on creating investment"))
-
+					WithArgs(loanID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}).
+						AddRow(1, loanID, decimal.NewFromInt(300), investorID).
+						AddRow(2, loanID, decimal.NewFromInt(100), investorID+1))
 				mockSql.ExpectRollback()
 			},
-			wantErr: fmt.Errorf("DB error on creating investment"),
+			wantErr: &errs.LimitExceededError{
+				Message: errs.ErrInvestorLoanShareExceeded,
+				Current: "300.00 IDR",
+				Limit:   "500.00 IDR",
+			},
 		},
 		{
-			name: "failure due to DB error on updating loan status",
+			name: "failure due to investor exposure to the borrower",
 			args: args{
 				ctx: context.Background(),
 				investmentRequest: entity.RequestAddInvestment{
 					LoanID: loanID,
-					Amount: principal,
+					Amount: amount,
 				},
 				investorID: investorID,
 			},
+			policy: usecase.LoanPolicy{MaxBorrowerExposure: money.Amounts{money.IDR: decimal.NewFromInt(2000)}},
 			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
 				lockKey := fmt.Sprintf("event_lock:%d", loanID)
 				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
@@ -807,40 +1852,24 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 				mockSql.ExpectBegin()
 				mockSql.ExpectQuery(`SELECT .* FROM "loans"`).
 					WithArgs(loanID, constants.StatusApproved, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "status"}).
-						AddRow(loanID, principal, constants.StatusApproved))
-
-				// Mock loan.Investments preload (empty in this test)
+					WillReturnRows(sqlmock.NewRows([]string{"id", "borrower_id", "principal", "currency", "status"}).
+						AddRow(loanID, 5, principal, money.IDR, constants.StatusApproved))
 				mockSql.ExpectQuery(`SELECT .* FROM "investments"`).
-					WithArgs(loanID).
+					WithArgs(loanID, constants.InvestmentActive).
 					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "amount", "investor_id"}))
-
-				mockSql.ExpectQuery(`INSERT INTO "investments"`).
-					WithArgs(
-						sqlmock.AnyArg(),
-						sqlmock.AnyArg(),
-						loanID,
-						investorID,
-						principal,
-					).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
-
-				mockSql.ExpectExec(`UPDATE "loans"`).
-					WithArgs(
-						sqlmock.AnyArg(),
-						sqlmock.AnyArg(),
-						sqlmock.AnyArg(),
-						principal,
-						sqlmock.AnyArg(),
-						sqlmock.AnyArg(),
-						constants.StatusInvested,
-						sqlmock.AnyArg(),
-						loanID,
-					).
-					WillReturnError(fmt.Errorf("DB error on updating loan status"))
-
+				mockSql.ExpectQuery(`SELECT COALESCE\(SUM\(investments.amount\), 0\) FROM "investments" JOIN loans`).
+					WithArgs(investorID, constants.InvestmentActive, 5, money.IDR).
+					WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(decimal.NewFromInt(2500)))
+				mockSql.ExpectQuery(`SELECT COALESCE\(SUM\(payouts.principal\), 0\) FROM "payouts" JOIN loans`).
+					WithArgs(investorID, 5, money.IDR).
+					WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(decimal.NewFromInt(800)))
 				mockSql.ExpectRollback()
 			},
-			wantErr: fmt.Errorf("DB error on updating loan status"),
+			wantErr: &errs.LimitExceededError{
+				Message: errs.ErrInvestorExposureExceeded,
+				Current: "1700.00 IDR",
+				Limit:   "2000.00 IDR",
+			},
 		},
 	}
 
@@ -848,11 +1877,13 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			db, mockSql := setupMockDB(t)
 			redis, mockRedis := redismock.NewClientMock()
-			u := usecase.NewLoanUsecase(db, redis)
+			notifier := &fakeNotifier{err: tt.notifyErr}
+			documents := &fakeDocuments{}
+			u := usecase.NewLoanUsecase(db, redis, notifier, documents, tt.policy)
 			if tt.mockFunc != nil {
 				tt.mockFunc(mockSql, mockRedis)
 			}
-			got, err := u.AddInvestment(tt.args.ctx, tt.args.investmentRequest, tt.args.investorID)
+			got, err := u.AddInvestment(tt.args.ctx, tt.args.investmentRequest, entity.Actor{ID: tt.args.investorID, Role: constants.RoleInvestor})
 			if tt.wantErr != nil {
 				assert.Error(t, err)
 				assert.Equal(t, tt.wantErr, err)
@@ -863,9 +1894,27 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 				assert.Equal(t, tt.want.LoanID, got.LoanID)
 				assert.Equal(t, tt.want.InvestorID, got.InvestorID)
 				assert.Equal(t, tt.want.Amount, got.Amount)
+				assert.Equal(t, tt.want.Status, got.Status)
 			}
+			assert.Equal(t, tt.wantNotified, notifier.invested)
+			assert.Equal(t, len(tt.wantNotified), notifier.woken)
 			assert.NoError(t, mockSql.ExpectationsWereMet())
 			assert.NoError(t, mockRedis.ExpectationsWereMet())
+			// the agreements of an investment that did not commit are removed again
+			if tt.wantErr != nil {
+				assert.Empty(t, documents.saved)
+			}
+			var agreements []uint
+			for _, document := range append(documents.saved, documents.discarded...) {
+				assert.Equal(t, constants.DocumentInvestmentAgreement, document.Kind)
+				assert.Equal(t, tt.args.investorID, document.OwnerID)
+				assert.Equal(t, &tt.args.investmentRequest.LoanID, document.LoanID)
+				var investmentID uint
+				_, err := fmt.Sscanf(document.Name, "investment_agreement_%d.pdf", &investmentID)
+				assert.NoError(t, err)
+				agreements = append(agreements, investmentID)
+			}
+			assert.Equal(t, tt.wantAgreements, agreements)
 		})
 	}
 }
@@ -873,7 +1922,8 @@ func TestLoanUsecase_AddInvestment(t *testing.T) {
 func TestLoanUsecase_DisburseLoan(t *testing.T) {
 	loanID := uint(1)
 	disburserID := uint(2)
-	signedAgreementURL := "https://example.com/signed_agreement.pdf"
+	signedAgreementID := uint(5)
+	signedAgreementURL := "https://documents.test/5"
 	disbursementID := uint(3)
 	type args struct {
 		disbursementRequest entity.RequestDisburseLoan
@@ -890,8 +1940,8 @@ func TestLoanUsecase_DisburseLoan(t *testing.T) {
 			name: "DisburseLoan_Success",
 			args: args{
 				disbursementRequest: entity.RequestDisburseLoan{
-					LoanID:             loanID,
-					SignedAgreementURL: signedAgreementURL,
+					LoanID:                    loanID,
+					SignedAgreementDocumentID: signedAgreementID,
 				},
 				disburserID: disburserID,
 			},
@@ -899,14 +1949,17 @@ func TestLoanUsecase_DisburseLoan(t *testing.T) {
 				mockSql.ExpectQuery(regexp.QuoteMeta(
 					`SELECT * FROM "loans"`)).
 					WithArgs(loanID, constants.StatusInvested, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(loanID, constants.StatusInvested))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "principal", "rate", "tenor", "frequency"}).
+						AddRow(loanID, constants.StatusInvested, 1000, 10, 2, constants.FrequencyMonthly))
+				expectAttachedDocument(mockSql, signedAgreementID, constants.DocumentSignedAgreement, loanID)
 				mockSql.ExpectBegin()
 				mockSql.ExpectExec(regexp.QuoteMeta(
 					`UPDATE "loans"`)).
 					WithArgs(
-						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusDisbursed, sqlmock.AnyArg(), loanID,
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusDisbursed, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID,
 					).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventDisburse, constants.StatusInvested, constants.StatusDisbursed, entity.Actor{ID: disburserID, Role: constants.RoleDisburser})
 				mockSql.ExpectQuery(regexp.QuoteMeta(
 					`INSERT INTO "loan_disbursements"`)).
 					WithArgs(
@@ -914,10 +1967,20 @@ func TestLoanUsecase_DisburseLoan(t *testing.T) {
 						sqlmock.AnyArg(),
 						loanID,
 						signedAgreementURL,
+						signedAgreementID,
 						disburserID,
 						sqlmock.AnyArg(),
 					).
 					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "signed_agreement_url", "disburser_id"}).AddRow(disbursementID, loanID, signedAgreementURL, disburserID))
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`INSERT INTO "installments"`)).
+					WithArgs(
+						sqlmock.AnyArg(), sqlmock.AnyArg(), loanID, 1, sqlmock.AnyArg(), decimal.NewFromInt(500), decimal.NewFromInt(50), decimal.NewFromInt(550),
+						decimal.NewFromInt(500), decimal.Zero, decimal.Zero, constants.InstallmentPending, nil,
+						sqlmock.AnyArg(), sqlmock.AnyArg(), loanID, 2, sqlmock.AnyArg(), decimal.NewFromInt(500), decimal.NewFromInt(50), decimal.NewFromInt(550),
+						decimal.Zero, decimal.Zero, decimal.Zero, constants.InstallmentPending, nil,
+					).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
 				mockSql.ExpectCommit()
 			},
 			want: &entity.LoanDisbursement{
@@ -931,8 +1994,8 @@ func TestLoanUsecase_DisburseLoan(t *testing.T) {
 			name: "DisburseLoan_Failure_LoanNotFound",
 			args: args{
 				disbursementRequest: entity.RequestDisburseLoan{
-					LoanID:             loanID,
-					SignedAgreementURL: signedAgreementURL,
+					LoanID:                    loanID,
+					SignedAgreementDocumentID: signedAgreementID,
 				},
 				disburserID: disburserID,
 			},
@@ -944,12 +2007,33 @@ func TestLoanUsecase_DisburseLoan(t *testing.T) {
 			},
 			wantErr: gorm.ErrRecordNotFound,
 		},
+		{
+			name: "DisburseLoan_Failure_SignedAgreementNotAttached",
+			args: args{
+				disbursementRequest: entity.RequestDisburseLoan{
+					LoanID:                    loanID,
+					SignedAgreementDocumentID: signedAgreementID,
+				},
+				disburserID: disburserID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs(loanID, constants.StatusInvested, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "principal", "rate", "tenor", "frequency"}).
+						AddRow(loanID, constants.StatusInvested, 1000, 10, 2, constants.FrequencyMonthly))
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "documents" WHERE id = $1 AND kind = $2 AND loan_id = $3`)).
+					WithArgs(signedAgreementID, constants.DocumentSignedAgreement, loanID, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: fmt.Errorf(errs.ErrDocumentNotAttached),
+		},
 		{
 			name: "DisburseLoan_Failure_DBError_UpdateLoan",
 			args: args{
 				disbursementRequest: entity.RequestDisburseLoan{
-					LoanID:             loanID,
-					SignedAgreementURL: signedAgreementURL,
+					LoanID:                    loanID,
+					SignedAgreementDocumentID: signedAgreementID,
 				},
 				disburserID: disburserID,
 			},
@@ -957,12 +2041,14 @@ func TestLoanUsecase_DisburseLoan(t *testing.T) {
 				mockSql.ExpectQuery(regexp.QuoteMeta(
 					`SELECT * FROM "loans"`)).
 					WithArgs(loanID, constants.StatusInvested, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(loanID, constants.StatusInvested))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "principal", "rate", "tenor", "frequency"}).
+						AddRow(loanID, constants.StatusInvested, 1000, 10, 2, constants.FrequencyMonthly))
+				expectAttachedDocument(mockSql, signedAgreementID, constants.DocumentSignedAgreement, loanID)
 				mockSql.ExpectBegin()
 				mockSql.ExpectExec(regexp.QuoteMeta(
 					`UPDATE "loans"`)).
 					WithArgs(
-						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusDisbursed, sqlmock.AnyArg(), loanID,
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusDisbursed, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID,
 					).
 					WillReturnError(fmt.Errorf("DB error on update loan"))
 				mockSql.ExpectRollback()
@@ -973,8 +2059,8 @@ func TestLoanUsecase_DisburseLoan(t *testing.T) {
 			name: "DisburseLoan_Failure_DBError_InsertDisbursement",
 			args: args{
 				disbursementRequest: entity.RequestDisburseLoan{
-					LoanID:             loanID,
-					SignedAgreementURL: signedAgreementURL,
+					LoanID:                    loanID,
+					SignedAgreementDocumentID: signedAgreementID,
 				},
 				disburserID: disburserID,
 			},
@@ -982,14 +2068,17 @@ func TestLoanUsecase_DisburseLoan(t *testing.T) {
 				mockSql.ExpectQuery(regexp.QuoteMeta(
 					`SELECT * FROM "loans"`)).
 					WithArgs(loanID, constants.StatusInvested, 1).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(loanID, constants.StatusInvested))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "principal", "rate", "tenor", "frequency"}).
+						AddRow(loanID, constants.StatusInvested, 1000, 10, 2, constants.FrequencyMonthly))
+				expectAttachedDocument(mockSql, signedAgreementID, constants.DocumentSignedAgreement, loanID)
 				mockSql.ExpectBegin()
 				mockSql.ExpectExec(regexp.QuoteMeta(
 					`UPDATE "loans"`)).
 					WithArgs(
-						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusDisbursed, sqlmock.AnyArg(), loanID,
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusDisbursed, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID,
 					).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventDisburse, constants.StatusInvested, constants.StatusDisbursed, entity.Actor{ID: disburserID, Role: constants.RoleDisburser})
 				mockSql.ExpectQuery(regexp.QuoteMeta(
 					`INSERT INTO "loan_disbursements"`)).
 					WithArgs(
@@ -997,6 +2086,7 @@ func TestLoanUsecase_DisburseLoan(t *testing.T) {
 						sqlmock.AnyArg(),
 						loanID,
 						signedAgreementURL,
+						signedAgreementID,
 						disburserID,
 						sqlmock.AnyArg(),
 					).
@@ -1005,16 +2095,47 @@ func TestLoanUsecase_DisburseLoan(t *testing.T) {
 			},
 			wantErr: fmt.Errorf("DB error on insert disbursement"),
 		},
+		{
+			name: "DisburseLoan_Failure_DBError_InsertInstallments",
+			args: args{
+				disbursementRequest: entity.RequestDisburseLoan{
+					LoanID:                    loanID,
+					SignedAgreementDocumentID: signedAgreementID,
+				},
+				disburserID: disburserID,
+			},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs(loanID, constants.StatusInvested, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status", "principal", "rate", "tenor", "frequency"}).
+						AddRow(loanID, constants.StatusInvested, 1000, 10, 2, constants.FrequencyMonthly))
+				expectAttachedDocument(mockSql, signedAgreementID, constants.DocumentSignedAgreement, loanID)
+				mockSql.ExpectBegin()
+				mockSql.ExpectExec(regexp.QuoteMeta(
+					`UPDATE "loans"`)).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventDisburse, constants.StatusInvested, constants.StatusDisbursed, entity.Actor{ID: disburserID, Role: constants.RoleDisburser})
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`INSERT INTO "loan_disbursements"`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(disbursementID))
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`INSERT INTO "installments"`)).
+					WillReturnError(fmt.Errorf("DB error on insert installments"))
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf("DB error on insert installments"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db, mockSql := setupMockDB(t)
 			redis, mockRedis := redismock.NewClientMock()
-			u := usecase.NewLoanUsecase(db, redis)
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, usecase.LoanPolicy{})
 			if tt.mockFunc != nil {
 				tt.mockFunc(mockSql, mockRedis)
 			}
-			got, err := u.DisburseLoan(tt.args.disbursementRequest, tt.args.disburserID)
+			got, err := u.DisburseLoan(context.Background(), tt.args.disbursementRequest, entity.Actor{ID: tt.args.disburserID, Role: constants.RoleDisburser})
 			if tt.wantErr != nil {
 				assert.Error(t, err)
 				assert.Equal(t, tt.wantErr, err)
@@ -1073,7 +2194,7 @@ func TestLoanUsecase_GetLoan(t *testing.T) {
 					ID: 1,
 				},
 				Status:    constants.StatusDisbursed,
-				Principal: 1000,
+				Principal: decimal.NewFromInt(1000),
 				ApprovedInfo: &entity.LoanApproval{
 					LoanID:       1,
 					ValidatorID:  2,
@@ -1091,7 +2212,7 @@ func TestLoanUsecase_GetLoan(t *testing.T) {
 						},
 						LoanID:     1,
 						InvestorID: 4,
-						Amount:     1000,
+						Amount:     decimal.NewFromInt(1000),
 					},
 				},
 			},
@@ -1114,7 +2235,7 @@ func TestLoanUsecase_GetLoan(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			db, mockSql := setupMockDB(t)
 			redis, mockRedis := redismock.NewClientMock()
-			u := usecase.NewLoanUsecase(db, redis)
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, usecase.LoanPolicy{})
 			if tt.mockFunc != nil {
 				tt.mockFunc(mockSql, mockRedis)
 			}
@@ -1159,3 +2280,89 @@ func TestLoanUsecase_GetLoan(t *testing.T) {
 		})
 	}
 }
+
+func TestLoanUsecase_GetSchedule(t *testing.T) {
+	dueDate := time.Date(2025, 7, 14, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		loanID   string
+		mockFunc func(mockSql sqlmock.Sqlmock)
+		wantLen  int
+		wantErr  error
+	}{
+		{
+			name:   "GetSchedule_Success",
+			loanID: "1",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs("1", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, constants.StatusDisbursed))
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "installments" WHERE loan_id = $1 ORDER BY sequence`)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "sequence", "due_date", "principal", "interest", "amount", "outstanding_balance"}).
+						AddRow(1, 1, 1, dueDate, 500, 50, 550, 500).
+						AddRow(2, 1, 2, dueDate.AddDate(0, 1, 0), 500, 50, 550, 0))
+			},
+			wantLen: 2,
+		},
+		{
+			name:   "GetSchedule_Failure_LoanNotFound",
+			loanID: "1",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs("1", 1).
+					WillReturnError(gorm.ErrRecordNotFound)
+			},
+			wantErr: fmt.Errorf(errs.ErrLoanNotFound),
+		},
+		{
+			name:   "GetSchedule_Failure_DBError",
+			loanID: "1",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs("1", 1).
+					WillReturnError(fmt.Errorf("connection refused"))
+			},
+			wantErr: fmt.Errorf("connection refused"),
+		},
+		{
+			name:   "GetSchedule_Failure_NotDisbursed",
+			loanID: "1",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans"`)).
+					WithArgs("1", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, constants.StatusApproved))
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "installments"`)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: fmt.Errorf(errs.ErrScheduleNotAvailable),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			redis, _ := redismock.NewClientMock()
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, usecase.LoanPolicy{})
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+			got, err := u.GetSchedule(tt.loanID)
+			if tt.wantErr != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got, tt.wantLen)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
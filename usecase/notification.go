@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	"loan-service/utils/logger"
+	"loan-service/utils/mailer"
+	"loan-service/utils/money"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationUsecase struct {
+	db     *gorm.DB
+	mailer mailer.Mailer
+	// wake tells the delivery worker that notifications were queued, it holds at most one pending signal
+	wake chan struct{}
+}
+
+func NewNotificationUsecase(db *gorm.DB, mailer mailer.Mailer) *NotificationUsecase {
+	return &NotificationUsecase{
+		db:     db,
+		mailer: mailer,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// QueueLoanInvested records a pending agreement email for every investor of the loan with tx,
+// the emails are sent by the delivery worker once the transaction is committed
+func (u *NotificationUsecase) QueueLoanInvested(ctx context.Context, tx *gorm.DB, loanID uint) error {
+	var loan entity.Loan
+	if err := tx.Preload("Investments", "status = ?", constants.InvestmentActive).First(&loan, "id = ?", loanID).Error; err != nil {
+		logger.Error("Failed to fetch loan for notification", zap.Uint("loanID", loanID), zap.Error(err))
+		return err
+	}
+
+	agreementLink := ""
+	if loan.AgreementLink != nil {
+		agreementLink = *loan.AgreementLink
+	}
+	// an investor holding several investments in the loan has an agreement for each of them
+	investmentAgreements := map[uint][]string{}
+	for _, inv := range loan.Investments {
+		if inv.AgreementLink != nil {
+			investmentAgreements[inv.InvestorID] = append(investmentAgreements[inv.InvestorID], *inv.AgreementLink)
+		}
+	}
+
+	return u.queueForInvestors(tx, loan, constants.NotificationLoanInvested, func(investor entity.User, invested decimal.Decimal) (string, string) {
+		body := fmt.Sprintf(
+			"Hi %s,\n\nLoan #%d you invested in is now fully funded.\nYour investment: %s out of %s %s, with an ROI of %s%%.\n\nThe loan agreement letter is available at %s\n",
+			investor.Username, loan.ID, money.Format(invested, loan.Currency), money.Format(loan.Principal, loan.Currency), loan.Currency,
+			loan.ROI.StringFixed(2), agreementLink,
+		)
+		if links := investmentAgreements[investor.ID]; len(links) > 0 {
+			body += fmt.Sprintf("Your investment agreement is available at %s\n", strings.Join(links, ", "))
+		}
+		return fmt.Sprintf("Loan #%d is fully funded", loan.ID), body
+	})
+}
+
+// QueueLoanExpired records with tx an email telling the investors of an expired loan that their investments were released
+func (u *NotificationUsecase) QueueLoanExpired(ctx context.Context, tx *gorm.DB, loanID uint) error {
+	var loan entity.Loan
+	if err := tx.Preload("Investments", "status = ?", constants.InvestmentVoided).First(&loan, "id = ?", loanID).Error; err != nil {
+		logger.Error("Failed to fetch loan for notification", zap.Uint("loanID", loanID), zap.Error(err))
+		return err
+	}
+
+	return u.queueForInvestors(tx, loan, constants.NotificationLoanExpired, func(investor entity.User, invested decimal.Decimal) (string, string) {
+		return fmt.Sprintf("Loan #%d has expired", loan.ID), fmt.Sprintf(
+			"Hi %s,\n\nLoan #%d you invested in did not reach its funding target of %s %s before the funding deadline.\nYour investment of %s %s has been released and refunded.\n",
+			investor.Username, loan.ID, money.Format(loan.Principal, loan.Currency), loan.Currency, money.Format(invested, loan.Currency), loan.Currency,
+		)
+	})
+}
+
+// queueForInvestors records with tx one pending email per investor of the preloaded investments,
+// compose returns the subject and body for an investor and the total amount they invested
+func (u *NotificationUsecase) queueForInvestors(
+	tx *gorm.DB,
+	loan entity.Loan,
+	kind constants.NotificationKind,
+	compose func(investor entity.User, invested decimal.Decimal) (string, string),
+) error {
+	invested := map[uint]decimal.Decimal{}
+	investorIDs := []uint{}
+	for _, inv := range loan.Investments {
+		if _, ok := invested[inv.InvestorID]; !ok {
+			investorIDs = append(investorIDs, inv.InvestorID)
+		}
+		invested[inv.InvestorID] = invested[inv.InvestorID].Add(inv.Amount)
+	}
+	if len(investorIDs) == 0 {
+		return nil
+	}
+
+	var investors []entity.User
+	if err := tx.Where("id IN ?", investorIDs).Order("id").Find(&investors).Error; err != nil {
+		logger.Error("Failed to fetch investors for notification", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return err
+	}
+
+	notifications := make([]entity.Notification, 0, len(investors))
+	for _, investor := range investors {
+		if investor.Email == "" {
+			logger.Warn("Investor has no email address, skipping notification", zap.Uint("loanID", loan.ID), zap.Uint("investorID", investor.ID))
+			continue
+		}
+		subject, body := compose(investor, invested[investor.ID])
+		notifications = append(notifications, entity.Notification{
+			Kind:      kind,
+			LoanID:    loan.ID,
+			UserID:    investor.ID,
+			Recipient: investor.Email,
+			Subject:   subject,
+			Body:      body,
+			Status:    constants.NotificationPending,
+		})
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	if err := tx.Create(&notifications).Error; err != nil {
+		logger.Error("Failed to record notifications", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Wake asks the delivery worker to send the queued notifications now instead of on its next tick, it never blocks
+func (u *NotificationUsecase) Wake() {
+	select {
+	case u.wake <- struct{}{}:
+	default:
+	}
+}
+
+// DeliverPending attempts to deliver every pending notification and every failed one that has not run out of attempts,
+// each one is claimed with a row lock first so a notification is never sent twice by concurrent workers
+func (u *NotificationUsecase) DeliverPending(ctx context.Context) error {
+	var ids []uint
+	if err := u.deliverable(u.db).Model(&entity.Notification{}).Order("id").Pluck("id", &ids).Error; err != nil {
+		logger.Error("Failed to fetch notifications to deliver", zap.Error(err))
+		return err
+	}
+
+	for _, id := range ids {
+		u.claimAndDeliver(ctx, id)
+	}
+	return nil
+}
+
+func (u *NotificationUsecase) deliverable(db *gorm.DB) *gorm.DB {
+	return db.Where("status IN ? AND attempts < ?", []constants.NotificationStatus{constants.NotificationPending, constants.NotificationFailed}, constants.MaxNotificationAttempts)
+}
+
+// claimAndDeliver holds the row lock of the notification while it is sent, a notification locked by another worker
+// or already delivered since it was listed is skipped
+func (u *NotificationUsecase) claimAndDeliver(ctx context.Context, id uint) {
+	tx := u.db.Begin()
+	defer tx.Rollback()
+
+	var notification entity.Notification
+	err := u.deliverable(tx).Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("id = ?", id).Take(&notification).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Error("Failed to claim notification", zap.Uint("notificationID", id), zap.Error(err))
+		}
+		return
+	}
+
+	u.deliver(ctx, tx, &notification)
+	if err := tx.Commit().Error; err != nil {
+		logger.Error("Failed to commit notification delivery", zap.Uint("notificationID", id), zap.Error(err))
+	}
+}
+
+// RunDeliveryWorker calls DeliverPending on every tick and when woken until the context is cancelled,
+// notifications are only sent from this worker so a slow mail server never holds up a request
+func (u *NotificationUsecase) RunDeliveryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-u.wake:
+		}
+		u.DeliverPending(ctx)
+	}
+}
+
+func (u *NotificationUsecase) deliver(ctx context.Context, tx *gorm.DB, notification *entity.Notification) {
+	notification.Attempts++
+	err := u.mailer.Send(ctx, mailer.Message{
+		To:      []string{notification.Recipient},
+		Subject: notification.Subject,
+		Body:    notification.Body,
+	})
+	if err != nil {
+		logger.Warn("Failed to deliver notification", zap.Uint("notificationID", notification.ID), zap.Int("attempts", notification.Attempts), zap.Error(err))
+		lastError := err.Error()
+		notification.Status = constants.NotificationFailed
+		notification.LastError = &lastError
+	} else {
+		sentAt := time.Now()
+		notification.Status = constants.NotificationSent
+		notification.SentAt = &sentAt
+		notification.LastError = nil
+	}
+
+	if err := tx.Save(notification).Error; err != nil {
+		logger.Error("Failed to update notification delivery", zap.Uint("notificationID", notification.ID), zap.Error(err))
+	}
+}
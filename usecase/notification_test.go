@@ -0,0 +1,311 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"loan-service/entity"
+	"loan-service/usecase"
+	"loan-service/utils/constants"
+	"loan-service/utils/mailer"
+	"loan-service/utils/money"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type fakeMailer struct {
+	sent []mailer.Message
+	err  error
+}
+
+func (f *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	f.sent = append(f.sent, msg)
+	return f.err
+}
+
+func TestNotificationUsecase_QueueLoanInvested(t *testing.T) {
+	loanID := uint(1)
+	tests := []struct {
+		name     string
+		mockFunc func(mockSql sqlmock.Sqlmock)
+		// wantInBody are parts of the body queued for the first investor
+		wantInBody []string
+		wantErr    error
+	}{
+		{
+			name: "QueueLoanInvested_Success",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans" WHERE id = $1`)).
+					WithArgs(loanID, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "roi", "status", "agreement_link"}).
+						AddRow(loanID, 1000, money.IDR, 8, constants.StatusInvested, "https://example.com/agreement/1"))
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "investments" WHERE "investments"."loan_id" = $1 AND status = $2`)).
+					WithArgs(loanID, constants.InvestmentActive).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "investor_id", "amount", "agreement_link"}).
+						AddRow(1, loanID, 3, 400, "https://example.com/investments/1/investment_agreement_1.pdf").
+						AddRow(2, loanID, 4, 500, "https://example.com/investments/2/investment_agreement_2.pdf").
+						AddRow(3, loanID, 3, 100, "https://example.com/investments/3/investment_agreement_3.pdf"))
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id IN ($1,$2) ORDER BY id`)).
+					WithArgs(3, 4).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email"}).
+						AddRow(3, "investor1", "investor1@example.com").
+						AddRow(4, "investor2", ""))
+
+				// the investor without an email address is skipped
+				mockSql.ExpectQuery(`INSERT INTO "notifications"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						constants.NotificationLoanInvested,
+						loanID,
+						3,
+						"investor1@example.com",
+						"Loan #1 is fully funded",
+						sqlmock.AnyArg(),
+						constants.NotificationPending,
+						0,
+						nil,
+						nil,
+					).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mockSql.ExpectCommit()
+			},
+			wantInBody: []string{
+				"The loan agreement letter is available at https://example.com/agreement/1\n",
+				"Your investment agreement is available at https://example.com/investments/1/investment_agreement_1.pdf, " +
+					"https://example.com/investments/3/investment_agreement_3.pdf\n",
+			},
+		},
+		{
+			name: "QueueLoanInvested_Failure_LoanNotFound",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans"`)).
+					WillReturnError(fmt.Errorf("DB error"))
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf("DB error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			m := &fakeMailer{}
+			u := usecase.NewNotificationUsecase(db, m)
+			var bodies []string
+			db.Callback().Create().Before("gorm:create").Register("capture_body", func(tx *gorm.DB) {
+				if notifications, ok := tx.Statement.Dest.(*[]entity.Notification); ok {
+					for _, n := range *notifications {
+						bodies = append(bodies, n.Body)
+					}
+				}
+			})
+			mockSql.ExpectBegin()
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+
+			tx := db.Begin()
+			err := u.QueueLoanInvested(context.Background(), tx, loanID)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				tx.Rollback()
+			} else {
+				assert.NoError(t, err)
+				tx.Commit()
+			}
+			// nothing is sent before the delivery worker picks the notifications up
+			assert.Empty(t, m.sent)
+			for _, part := range tt.wantInBody {
+				assert.Contains(t, bodies[0], part)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestNotificationUsecase_QueueLoanExpired(t *testing.T) {
+	loanID := uint(1)
+	db, mockSql := setupMockDB(t)
+	m := &fakeMailer{}
+	u := usecase.NewNotificationUsecase(db, m)
+
+	mockSql.ExpectBegin()
+	mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans" WHERE id = $1`)).
+		WithArgs(loanID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "principal", "currency", "roi", "status"}).
+			AddRow(loanID, 1000, money.SGD, 8, constants.StatusExpired))
+	mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "investments" WHERE "investments"."loan_id" = $1 AND status = $2`)).
+		WithArgs(loanID, constants.InvestmentVoided).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "investor_id", "amount", "status"}).
+			AddRow(1, loanID, 3, 400, constants.InvestmentVoided))
+	mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id IN ($1) ORDER BY id`)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email"}).
+			AddRow(3, "investor1", "investor1@example.com"))
+	mockSql.ExpectQuery(`INSERT INTO "notifications"`).
+		WithArgs(
+			sqlmock.AnyArg(),
+			sqlmock.AnyArg(),
+			constants.NotificationLoanExpired,
+			loanID,
+			3,
+			"investor1@example.com",
+			"Loan #1 has expired",
+			sqlmock.AnyArg(),
+			constants.NotificationPending,
+			0,
+			nil,
+			nil,
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mockSql.ExpectCommit()
+
+	var body string
+	db.Callback().Create().Before("gorm:create").Register("capture_body", func(tx *gorm.DB) {
+		if notifications, ok := tx.Statement.Dest.(*[]entity.Notification); ok {
+			body = (*notifications)[0].Body
+		}
+	})
+
+	tx := db.Begin()
+	err := u.QueueLoanExpired(context.Background(), tx, loanID)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit().Error)
+	assert.Empty(t, m.sent)
+	assert.Contains(t, body, "funding target of 1000.00 SGD before the funding deadline")
+	assert.Contains(t, body, "Your investment of 400.00 SGD has been released and refunded.")
+	assert.NoError(t, mockSql.ExpectationsWereMet())
+}
+
+func TestNotificationUsecase_DeliverPending(t *testing.T) {
+	notificationColumns := []string{"id", "kind", "loan_id", "user_id", "recipient", "status", "attempts", "last_error"}
+	expectDeliverable := func(mockSql sqlmock.Sqlmock, ids ...int) {
+		rows := sqlmock.NewRows([]string{"id"})
+		for _, id := range ids {
+			rows.AddRow(id)
+		}
+		mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "notifications" WHERE status IN ($1,$2) AND attempts < $3 ORDER BY id`)).
+			WithArgs(constants.NotificationPending, constants.NotificationFailed, constants.MaxNotificationAttempts).
+			WillReturnRows(rows)
+	}
+	expectClaim := func(mockSql sqlmock.Sqlmock, id int) *sqlmock.ExpectedQuery {
+		mockSql.ExpectBegin()
+		return mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "notifications" WHERE (status IN ($1,$2) AND attempts < $3) AND id = $4 LIMIT $5 FOR UPDATE SKIP LOCKED`)).
+			WithArgs(constants.NotificationPending, constants.NotificationFailed, constants.MaxNotificationAttempts, id, 1)
+	}
+
+	tests := []struct {
+		name       string
+		mailErr    error
+		mockFunc   func(mockSql sqlmock.Sqlmock)
+		wantSentTo [][]string
+	}{
+		{
+			name: "DeliverPending_Success",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectDeliverable(mockSql, 6, 7)
+				expectClaim(mockSql, 6).
+					WillReturnRows(sqlmock.NewRows(notificationColumns).
+						AddRow(6, constants.NotificationLoanInvested, 1, 4, "investor2@example.com", constants.NotificationPending, 0, nil))
+				mockSql.ExpectExec(`UPDATE "notifications"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						constants.NotificationLoanInvested,
+						1,
+						4,
+						"investor2@example.com",
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						constants.NotificationSent,
+						1,
+						nil,
+						sqlmock.AnyArg(),
+						6,
+					).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mockSql.ExpectCommit()
+				expectClaim(mockSql, 7).
+					WillReturnRows(sqlmock.NewRows(notificationColumns).
+						AddRow(7, constants.NotificationLoanInvested, 1, 3, "investor1@example.com", constants.NotificationFailed, 2, "smtp unavailable"))
+				mockSql.ExpectExec(`UPDATE "notifications"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						constants.NotificationLoanInvested,
+						1,
+						3,
+						"investor1@example.com",
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						constants.NotificationSent,
+						3,
+						nil,
+						sqlmock.AnyArg(),
+						7,
+					).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mockSql.ExpectCommit()
+			},
+			wantSentTo: [][]string{{"investor2@example.com"}, {"investor1@example.com"}},
+		},
+		{
+			name:    "DeliverPending_DeliveryFailure_KeptForRetry",
+			mailErr: fmt.Errorf("smtp unavailable"),
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectDeliverable(mockSql, 1)
+				expectClaim(mockSql, 1).
+					WillReturnRows(sqlmock.NewRows(notificationColumns).
+						AddRow(1, constants.NotificationLoanInvested, 1, 3, "investor1@example.com", constants.NotificationPending, 0, nil))
+				mockSql.ExpectExec(`UPDATE "notifications"`).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						constants.NotificationLoanInvested,
+						1,
+						3,
+						"investor1@example.com",
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						constants.NotificationFailed,
+						1,
+						"smtp unavailable",
+						nil,
+						1,
+					).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mockSql.ExpectCommit()
+			},
+			wantSentTo: [][]string{{"investor1@example.com"}},
+		},
+		{
+			name: "DeliverPending_ClaimedByAnotherWorker_Skipped",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				expectDeliverable(mockSql, 1)
+				expectClaim(mockSql, 1).WillReturnRows(sqlmock.NewRows(notificationColumns))
+				mockSql.ExpectRollback()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			m := &fakeMailer{err: tt.mailErr}
+			u := usecase.NewNotificationUsecase(db, m)
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+			err := u.DeliverPending(context.Background())
+			assert.NoError(t, err)
+			var sentTo [][]string
+			for _, msg := range m.sent {
+				sentTo = append(sentTo, msg.To)
+			}
+			assert.Equal(t, tt.wantSentTo, sentTo)
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
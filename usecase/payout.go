@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"loan-service/entity"
+	"loan-service/utils/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// DistributeRepayment splits the principal and interest of a repayment across the loan investments
+// in proportion to their amount. Investors earn ROI out of the Rate paid by the borrower, the spread
+// between the two is kept by the platform. Rounding leftovers go to the last investment.
+func DistributeRepayment(loan entity.Loan, investments []entity.Investment, principal, interest decimal.Decimal) ([]entity.Payout, decimal.Decimal) {
+	investorInterest := interest
+	if loan.Rate.IsPositive() && loan.ROI.LessThan(loan.Rate) {
+		investorInterest = money.Round(interest.Mul(loan.ROI).Div(loan.Rate), loan.Currency)
+	}
+	platformFee := interest.Sub(investorInterest)
+
+	if len(investments) == 0 || !loan.Principal.IsPositive() {
+		return nil, platformFee
+	}
+
+	remainingPrincipal := principal
+	remainingReturn := investorInterest
+	payouts := make([]entity.Payout, 0, len(investments))
+	for i, inv := range investments {
+		payoutPrincipal := money.Round(principal.Mul(inv.Amount).Div(loan.Principal), loan.Currency)
+		payoutReturn := money.Round(investorInterest.Mul(inv.Amount).Div(loan.Principal), loan.Currency)
+		if i == len(investments)-1 {
+			payoutPrincipal, payoutReturn = remainingPrincipal, remainingReturn
+		}
+		remainingPrincipal = remainingPrincipal.Sub(payoutPrincipal)
+		remainingReturn = remainingReturn.Sub(payoutReturn)
+
+		payouts = append(payouts, entity.Payout{
+			LoanID:       loan.ID,
+			InvestmentID: inv.ID,
+			InvestorID:   inv.InvestorID,
+			Principal:    payoutPrincipal,
+			Return:       payoutReturn,
+			Amount:       payoutPrincipal.Add(payoutReturn),
+		})
+	}
+
+	return payouts, platformFee
+}
@@ -0,0 +1,76 @@
+package usecase_test
+
+import (
+	"loan-service/entity"
+	"loan-service/usecase"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistributeRepayment(t *testing.T) {
+	loan := entity.Loan{
+		DBCommon:  entity.DBCommon{ID: 1},
+		Principal: decimal.NewFromInt(900),
+		Rate:      decimal.NewFromInt(12),
+		ROI:       decimal.NewFromInt(9),
+	}
+	investments := []entity.Investment{
+		{DBCommon: entity.DBCommon{ID: 1}, LoanID: 1, InvestorID: 3, Amount: decimal.NewFromInt(300)},
+		{DBCommon: entity.DBCommon{ID: 2}, LoanID: 1, InvestorID: 4, Amount: decimal.NewFromInt(300)},
+		{DBCommon: entity.DBCommon{ID: 3}, LoanID: 1, InvestorID: 5, Amount: decimal.NewFromInt(300)},
+	}
+	tests := []struct {
+		name            string
+		loan            entity.Loan
+		investments     []entity.Investment
+		principal       float64
+		interest        float64
+		wantPrincipal   []float64
+		wantReturn      []float64
+		wantPlatformFee float64
+	}{
+		{
+			name:            "splits pro-rata and keeps the rate and roi spread",
+			loan:            loan,
+			investments:     investments,
+			principal:       300,
+			interest:        36,
+			wantPrincipal:   []float64{100, 100, 100},
+			wantReturn:      []float64{9, 9, 9},
+			wantPlatformFee: 9,
+		},
+		{
+			name:            "last investment absorbs rounding leftovers",
+			loan:            loan,
+			investments:     investments,
+			principal:       100,
+			interest:        10,
+			wantPrincipal:   []float64{33.33, 33.33, 33.34},
+			wantReturn:      []float64{2.5, 2.5, 2.5},
+			wantPlatformFee: 2.5,
+		},
+		{
+			name:            "no investments only books the platform fee",
+			loan:            loan,
+			principal:       100,
+			interest:        12,
+			wantPlatformFee: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payouts, fee := usecase.DistributeRepayment(tt.loan, tt.investments, decimal.NewFromFloat(tt.principal), decimal.NewFromFloat(tt.interest))
+			assertAmount(t, tt.wantPlatformFee, fee)
+			assert.Len(t, payouts, len(tt.wantPrincipal))
+			for i, payout := range payouts {
+				assert.Equal(t, tt.investments[i].ID, payout.InvestmentID)
+				assert.Equal(t, tt.investments[i].InvestorID, payout.InvestorID)
+				assertAmount(t, tt.wantPrincipal[i], payout.Principal)
+				assertAmount(t, tt.wantReturn[i], payout.Return)
+				assertAmount(t, tt.wantPrincipal[i]+tt.wantReturn[i], payout.Amount)
+			}
+		})
+	}
+}
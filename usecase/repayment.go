@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"loan-service/entity"
+	"loan-service/statemachine"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/logger"
+	"loan-service/utils/money"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// AddRepayment applies a borrower payment to the oldest open installments, settling the interest
+// of each installment before its principal, and pays it out to the loan investors
+func (u *LoanUsecase) AddRepayment(
+	ctx context.Context,
+	repaymentRequest entity.RequestAddRepayment,
+	payer entity.Actor,
+) (*entity.Repayment, error) {
+	unlock, err := u.lockLoan(ctx, repaymentRequest.LoanID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tx := u.db.Begin(&sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	defer tx.Rollback()
+
+	// every loan that is still repayable can be paid off
+	var loan entity.Loan
+	if err := tx.First(&loan, "id = ? AND status IN ?", repaymentRequest.LoanID, u.machine.From(statemachine.EventPayOff)).Error; err != nil {
+		logger.Error("Failed to find loan for repayment", zap.Uint("loanID", repaymentRequest.LoanID), zap.Error(err))
+		return nil, errors.New(errs.ErrLoanNotRepayable)
+	}
+	// an admin records the repayments the borrower made offline, the repayment keeps the admin as its payer
+	if payer.Role != constants.RoleAdmin && loan.BorrowerID != payer.ID {
+		return nil, errors.New(errs.ErrUnauthorizedAction)
+	}
+	// the amount is what the borrower paid, it is never rounded to fit the currency
+	if !money.FitsMinorUnits(repaymentRequest.Amount, loan.Currency) {
+		return nil, errors.New(errs.ErrInvalidMinorUnits)
+	}
+
+	var installments []entity.Installment
+	if err := tx.Where("loan_id = ? AND status <> ?", loan.ID, constants.InstallmentPaid).Order("sequence").Find(&installments).Error; err != nil {
+		logger.Error("Failed to fetch open installments", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+
+	outstanding := decimal.Zero
+	for _, inst := range installments {
+		outstanding = outstanding.Add(inst.Amount).Sub(inst.PaidPrincipal).Sub(inst.PaidInterest)
+	}
+	amount := repaymentRequest.Amount
+	if amount.GreaterThan(outstanding) {
+		return nil, errors.New(errs.ErrRepaymentExceedsOutstanding)
+	}
+
+	repayment := entity.Repayment{
+		LoanID:  loan.ID,
+		PayerID: payer.ID,
+		Amount:  amount,
+		PaidAt:  time.Now(),
+	}
+	remaining := amount
+	settled := 0
+	for i := range installments {
+		if !remaining.IsPositive() {
+			break
+		}
+		inst := &installments[i]
+
+		interest := decimal.Min(remaining, inst.Interest.Sub(inst.PaidInterest))
+		remaining = remaining.Sub(interest)
+		principal := decimal.Min(remaining, inst.Principal.Sub(inst.PaidPrincipal))
+		remaining = remaining.Sub(principal)
+
+		inst.PaidInterest = inst.PaidInterest.Add(interest)
+		inst.PaidPrincipal = inst.PaidPrincipal.Add(principal)
+		inst.Status = constants.InstallmentPartial
+		if inst.PaidInterest.Equal(inst.Interest) && inst.PaidPrincipal.Equal(inst.Principal) {
+			inst.Status = constants.InstallmentPaid
+			inst.PaidAt = &repayment.PaidAt
+			settled++
+		}
+		if err := tx.Save(inst).Error; err != nil {
+			logger.Error("Failed to update installment", zap.Uint("installmentID", inst.ID), zap.Error(err))
+			return nil, err
+		}
+
+		repayment.Interest = repayment.Interest.Add(interest)
+		repayment.Principal = repayment.Principal.Add(principal)
+		repayment.Allocations = append(repayment.Allocations, entity.RepaymentAllocation{
+			InstallmentID: inst.ID,
+			Principal:     principal,
+			Interest:      interest,
+		})
+	}
+
+	var investments []entity.Investment
+	if err := tx.Where("loan_id = ? AND status = ?", loan.ID, constants.InvestmentActive).Order("id").Find(&investments).Error; err != nil {
+		logger.Error("Failed to fetch loan investments", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+	repayment.Payouts, repayment.PlatformFee = DistributeRepayment(loan, investments, repayment.Principal, repayment.Interest)
+
+	if err := tx.Create(&repayment).Error; err != nil {
+		logger.Error("Failed to create repayment record", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+
+	var event statemachine.Event
+	if settled == len(installments) {
+		event = statemachine.EventPayOff
+	} else if loan.Status == constants.StatusDisbursed {
+		event = statemachine.EventRepay
+	}
+	if event != "" {
+		if err := u.machine.Fire(ctx, tx, &loan, event, payer, map[string]interface{}{"repayment_id": repayment.ID, "amount": repayment.Amount}); err != nil {
+			logger.Error("Failed to update loan repayment status", zap.Uint("loanID", loan.ID), zap.Error(err))
+			return nil, err
+		}
+	}
+
+	tx.Commit()
+
+	logger.Info("Repayment recorded", zap.Uint("loanID", loan.ID), zap.Uint("repaymentID", repayment.ID))
+
+	return &repayment, nil
+}
+
+// DefaultLoan marks a loan in repayment as defaulted, only loans with an overdue installment can default
+func (u *LoanUsecase) DefaultLoan(ctx context.Context, defaultRequest entity.RequestDefaultLoan, staff entity.Actor) (*entity.Loan, error) {
+	var loan entity.Loan
+	if err := u.db.First(&loan, "id = ? AND status IN ?", defaultRequest.LoanID, u.machine.From(statemachine.EventDefault)).Error; err != nil {
+		logger.Error("Failed to find loan to default", zap.Uint("loanID", defaultRequest.LoanID), zap.Error(err))
+		return nil, errors.New(errs.ErrLoanNotOverdue)
+	}
+
+	tx := u.db.Begin()
+	defer tx.Rollback()
+
+	if err := u.machine.Fire(ctx, tx, &loan, statemachine.EventDefault, staff, defaultRequest); err != nil {
+		logger.Error("Failed to update loan status to defaulted", zap.Uint("loanID", loan.ID), zap.Error(err))
+		return nil, err
+	}
+
+	tx.Commit()
+
+	logger.Info("Loan defaulted", zap.Uint("loanID", loan.ID), zap.Uint("staffID", staff.ID))
+
+	return &loan, nil
+}
+
+// requireOverdueInstallment guards the default transition, a loan can only default once an installment is overdue
+func requireOverdueInstallment(ctx context.Context, change statemachine.Change) error {
+	var overdue int64
+	if err := change.Tx.Model(&entity.Installment{}).
+		Where("loan_id = ? AND status <> ? AND due_date < ?", change.Loan.ID, constants.InstallmentPaid, time.Now()).
+		Count(&overdue).Error; err != nil {
+		return err
+	}
+	if overdue == 0 {
+		return errors.New(errs.ErrLoanNotOverdue)
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"loan-service/entity"
+	"loan-service/statemachine"
+	"loan-service/usecase"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-redis/redismock/v9"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestLoanUsecase_AddRepayment(t *testing.T) {
+	loanID := uint(1)
+	borrowerID := uint(2)
+	adminID := uint(9)
+	lockKey := fmt.Sprintf("event_lock:%d", loanID)
+	installmentColumns := []string{"id", "loan_id", "sequence", "principal", "interest", "amount", "paid_principal", "paid_interest", "status"}
+
+	expectLoan := func(mockSql sqlmock.Sqlmock, status constants.LoanStatus) {
+		mockSql.ExpectBegin()
+		mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans"`)).
+			WithArgs(loanID, constants.StatusDisbursed, constants.StatusRepaying, constants.StatusDefaulted, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "borrower_id", "principal", "rate", "roi", "status"}).AddRow(loanID, borrowerID, 1000, 10, 8, status))
+	}
+	expectInvestments := func(mockSql sqlmock.Sqlmock) {
+		mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "investments" WHERE loan_id = $1 AND status = $2 ORDER BY id`)).
+			WithArgs(loanID, constants.InvestmentActive).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "loan_id", "investor_id", "amount"}).
+				AddRow(20, loanID, 3, 600).
+				AddRow(21, loanID, 4, 400))
+	}
+
+	tests := []struct {
+		name          string
+		amount        float64
+		payerID       uint
+		payerRole     constants.UserRole
+		mockFunc      func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock)
+		wantPrincipal float64
+		wantInterest  float64
+		wantErr       error
+	}{
+		{
+			name:    "partial repayment covers interest before principal and starts repaying",
+			amount:  100,
+			payerID: borrowerID,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+				expectLoan(mockSql, constants.StatusDisbursed)
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "installments" WHERE loan_id = $1 AND status <> $2 ORDER BY sequence`)).
+					WithArgs(loanID, constants.InstallmentPaid).
+					WillReturnRows(sqlmock.NewRows(installmentColumns).
+						AddRow(10, loanID, 1, 500, 50, 550, 0, 0, constants.InstallmentPending).
+						AddRow(11, loanID, 2, 500, 50, 550, 0, 0, constants.InstallmentPending))
+				mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "installments"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), loanID, 1, sqlmock.AnyArg(), dec(500), dec(50), dec(550), dec(0), dec(50), dec(50), constants.InstallmentPartial, nil, 10).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectInvestments(mockSql)
+				mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "repayments"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), loanID, borrowerID, dec(100), dec(50), dec(50), dec(10), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "repayment_allocations"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 10, dec(50), dec(50)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "payouts"`)).
+					WithArgs(
+						sqlmock.AnyArg(), sqlmock.AnyArg(), 1, loanID, 20, 3, dec(30), dec(24), dec(54),
+						sqlmock.AnyArg(), sqlmock.AnyArg(), 1, loanID, 21, 4, dec(20), dec(16), dec(36),
+					).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+				mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "loans"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), borrowerID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusRepaying, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventRepay, constants.StatusDisbursed, constants.StatusRepaying, entity.Actor{ID: borrowerID, Role: constants.RoleBorrower})
+				mockSql.ExpectCommit()
+			},
+			wantPrincipal: 50,
+			wantInterest:  50,
+		},
+		{
+			name:      "admin records an offline repayment on behalf of the borrower",
+			amount:    100,
+			payerID:   adminID,
+			payerRole: constants.RoleAdmin,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+				expectLoan(mockSql, constants.StatusDisbursed)
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "installments" WHERE loan_id = $1 AND status <> $2 ORDER BY sequence`)).
+					WithArgs(loanID, constants.InstallmentPaid).
+					WillReturnRows(sqlmock.NewRows(installmentColumns).
+						AddRow(10, loanID, 1, 500, 50, 550, 0, 0, constants.InstallmentPending).
+						AddRow(11, loanID, 2, 500, 50, 550, 0, 0, constants.InstallmentPending))
+				mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "installments"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), loanID, 1, sqlmock.AnyArg(), dec(500), dec(50), dec(550), dec(0), dec(50), dec(50), constants.InstallmentPartial, nil, 10).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectInvestments(mockSql)
+				mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "repayments"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), loanID, adminID, dec(100), dec(50), dec(50), dec(10), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "repayment_allocations"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 10, dec(50), dec(50)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "payouts"`)).
+					WithArgs(
+						sqlmock.AnyArg(), sqlmock.AnyArg(), 1, loanID, 20, 3, dec(30), dec(24), dec(54),
+						sqlmock.AnyArg(), sqlmock.AnyArg(), 1, loanID, 21, 4, dec(20), dec(16), dec(36),
+					).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+				mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "loans"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), borrowerID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusRepaying, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventRepay, constants.StatusDisbursed, constants.StatusRepaying, entity.Actor{ID: adminID, Role: constants.RoleAdmin})
+				mockSql.ExpectCommit()
+			},
+			wantPrincipal: 50,
+			wantInterest:  50,
+		},
+		{
+			name:    "final repayment settles every installment and pays off the loan",
+			amount:  550,
+			payerID: borrowerID,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+				expectLoan(mockSql, constants.StatusRepaying)
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "installments"`)).
+					WithArgs(loanID, constants.InstallmentPaid).
+					WillReturnRows(sqlmock.NewRows(installmentColumns).
+						AddRow(11, loanID, 2, 500, 50, 550, 0, 0, constants.InstallmentPending))
+				mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "installments"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), loanID, 2, sqlmock.AnyArg(), dec(500), dec(50), dec(550), dec(0), dec(500), dec(50), constants.InstallmentPaid, sqlmock.AnyArg(), 11).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectInvestments(mockSql)
+				mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "repayments"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), loanID, borrowerID, dec(550), dec(500), dec(50), dec(10), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "repayment_allocations"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 2, 11, dec(500), dec(50)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mockSql.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "payouts"`)).
+					WithArgs(
+						sqlmock.AnyArg(), sqlmock.AnyArg(), 2, loanID, 20, 3, dec(300), dec(24), dec(324),
+						sqlmock.AnyArg(), sqlmock.AnyArg(), 2, loanID, 21, 4, dec(200), dec(16), dec(216),
+					).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(4))
+				mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "loans"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), borrowerID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusPaidOff, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventPayOff, constants.StatusRepaying, constants.StatusPaidOff, entity.Actor{ID: borrowerID, Role: constants.RoleBorrower})
+				mockSql.ExpectCommit()
+			},
+			wantPrincipal: 500,
+			wantInterest:  50,
+		},
+		{
+			name:    "failure due to repayment exceeding outstanding amount",
+			amount:  600,
+			payerID: borrowerID,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+				expectLoan(mockSql, constants.StatusRepaying)
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "installments"`)).
+					WithArgs(loanID, constants.InstallmentPaid).
+					WillReturnRows(sqlmock.NewRows(installmentColumns).
+						AddRow(11, loanID, 2, 500, 50, 550, 0, 0, constants.InstallmentPending))
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf(errs.ErrRepaymentExceedsOutstanding),
+		},
+		{
+			name:    "failure due to amount with more decimals than the currency allows",
+			amount:  100.005,
+			payerID: borrowerID,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+				expectLoan(mockSql, constants.StatusRepaying)
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf(errs.ErrInvalidMinorUnits),
+		},
+		{
+			name:    "failure due to payer not owning the loan",
+			amount:  100,
+			payerID: borrowerID + 1,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+				expectLoan(mockSql, constants.StatusRepaying)
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf(errs.ErrUnauthorizedAction),
+		},
+		{
+			name:    "failure due to loan not open for repayment",
+			amount:  100,
+			payerID: borrowerID,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(true)
+				mockRedis.ExpectDel(lockKey).SetVal(1)
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans"`)).
+					WillReturnError(gorm.ErrRecordNotFound)
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf(errs.ErrLoanNotRepayable),
+		},
+		{
+			name:    "failure due to failed to acquire lock",
+			amount:  100,
+			payerID: borrowerID,
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockRedis.ExpectSetNX(lockKey, "locked", 5*time.Second).SetVal(false)
+			},
+			wantErr: fmt.Errorf(errs.ErrBusySystem),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			redis, mockRedis := redismock.NewClientMock()
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, usecase.LoanPolicy{})
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql, mockRedis)
+			}
+			payerRole := tt.payerRole
+			if payerRole == "" {
+				payerRole = constants.RoleBorrower
+			}
+			got, err := u.AddRepayment(context.Background(), entity.RequestAddRepayment{LoanID: loanID, Amount: decimal.NewFromFloat(tt.amount)}, entity.Actor{ID: tt.payerID, Role: payerRole})
+			if tt.wantErr != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+				assertAmount(t, tt.amount, got.Amount)
+				assertAmount(t, tt.wantPrincipal, got.Principal)
+				assertAmount(t, tt.wantInterest, got.Interest)
+				assert.Len(t, got.Payouts, 2)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+			assert.NoError(t, mockRedis.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLoanUsecase_DefaultLoan(t *testing.T) {
+	loanID := uint(1)
+	tests := []struct {
+		name     string
+		mockFunc func(mockSql sqlmock.Sqlmock)
+		wantErr  error
+	}{
+		{
+			name: "DefaultLoan_Success",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans"`)).
+					WithArgs(loanID, constants.StatusDisbursed, constants.StatusRepaying, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(loanID, constants.StatusRepaying))
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "installments"`)).
+					WithArgs(loanID, constants.InstallmentPaid, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mockSql.ExpectExec(regexp.QuoteMeta(`UPDATE "loans"`)).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), constants.StatusDefaulted, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), loanID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectLoanEvent(mockSql, loanID, statemachine.EventDefault, constants.StatusRepaying, constants.StatusDefaulted, entity.Actor{ID: 5, Role: constants.RoleAdmin})
+				mockSql.ExpectCommit()
+			},
+		},
+		{
+			name: "DefaultLoan_Failure_NothingOverdue",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans"`)).
+					WithArgs(loanID, constants.StatusDisbursed, constants.StatusRepaying, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(loanID, constants.StatusRepaying))
+				mockSql.ExpectBegin()
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "installments"`)).
+					WithArgs(loanID, constants.InstallmentPaid, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf(errs.ErrLoanNotOverdue),
+		},
+		{
+			name: "DefaultLoan_Failure_LoanNotFound",
+			mockFunc: func(mockSql sqlmock.Sqlmock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "loans"`)).
+					WithArgs(loanID, constants.StatusDisbursed, constants.StatusRepaying, 1).
+					WillReturnError(gorm.ErrRecordNotFound)
+			},
+			wantErr: fmt.Errorf(errs.ErrLoanNotOverdue),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			redis, _ := redismock.NewClientMock()
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, usecase.LoanPolicy{})
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql)
+			}
+			got, err := u.DefaultLoan(context.Background(), entity.RequestDefaultLoan{LoanID: loanID}, entity.Actor{ID: 5, Role: constants.RoleAdmin})
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, constants.StatusDefaulted, got.Status)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/logger"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ResubmitLoan lets a borrower propose amended terms for one of their rejected loans. The new loan goes through the
+// same checks as a new proposal and links back to the rejected loan, which stays rejected.
+func (u *LoanUsecase) ResubmitLoan(ctx context.Context, resubmitRequest entity.RequestResubmitLoan, borrower entity.Actor) (*entity.Loan, error) {
+	var rejected entity.Loan
+	if err := u.db.First(&rejected, "id = ? AND status = ?", resubmitRequest.LoanID, constants.StatusRejected).Error; err != nil {
+		logger.Error("Failed to find loan to resubmit", zap.Uint("loanID", resubmitRequest.LoanID), zap.Error(err))
+		return nil, errors.New(errs.ErrLoanNotResubmittable)
+	}
+	if rejected.BorrowerID != borrower.ID {
+		return nil, errors.New(errs.ErrUnauthorizedAction)
+	}
+
+	return u.proposeLoan(ctx, resubmitRequest.RequestProposeLoan, borrower, &rejected, resubmitRequest)
+}
+
+// checkResubmission allows a single resubmission per rejected loan and caps the resubmissions of the borrower
+func (u *LoanUsecase) checkResubmission(tx *gorm.DB, rejected entity.Loan) error {
+	var resubmitted int64
+	if err := tx.Model(&entity.Loan{}).Where("resubmission_of = ?", rejected.ID).Count(&resubmitted).Error; err != nil {
+		logger.Error("Failed to count loan resubmissions", zap.Uint("loanID", rejected.ID), zap.Error(err))
+		return err
+	}
+	if resubmitted > 0 {
+		return errors.New(errs.ErrLoanAlreadyResubmitted)
+	}
+
+	if u.policy.MaxAppeals <= 0 {
+		return nil
+	}
+	var appeals int64
+	if err := tx.Model(&entity.Loan{}).
+		Where("borrower_id = ? AND resubmission_of IS NOT NULL", rejected.BorrowerID).
+		Count(&appeals).Error; err != nil {
+		logger.Error("Failed to count borrower resubmissions", zap.Uint("borrowerID", rejected.BorrowerID), zap.Error(err))
+		return err
+	}
+	if appeals >= int64(u.policy.MaxAppeals) {
+		return errors.New(errs.ErrAppealLimitReached)
+	}
+	return nil
+}
+
+// pgUniqueViolation is the postgres error code of a write rejected by a unique constraint
+const pgUniqueViolation = "23505"
+
+// isUniqueViolation tells whether the database rejected a write because of a unique constraint
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
+// loadResubmission describes what changed between a resubmitted loan and the rejected loan it replaces
+func (u *LoanUsecase) loadResubmission(loan *entity.Loan) error {
+	var previous entity.Loan
+	if err := u.db.Preload("ApprovedInfo").First(&previous, "id = ?", *loan.ResubmissionOf).Error; err != nil {
+		return err
+	}
+
+	resubmission := &entity.LoanResubmission{
+		PreviousLoanID: previous.ID,
+		Changes:        []entity.TermChange{},
+	}
+	if previous.ApprovedInfo != nil {
+		resubmission.RejectReason = previous.ApprovedInfo.RejectReason
+	}
+	terms := []struct {
+		field             string
+		previous, current string
+	}{
+		{"principal", previous.Principal.String(), loan.Principal.String()},
+		{"currency", string(previous.Currency), string(loan.Currency)},
+		{"rate", previous.Rate.String(), loan.Rate.String()},
+		{"roi", previous.ROI.String(), loan.ROI.String()},
+		{"tenor", strconv.Itoa(previous.Tenor), strconv.Itoa(loan.Tenor)},
+		{"frequency", string(previous.Frequency), string(loan.Frequency)},
+	}
+	for _, term := range terms {
+		if term.previous != term.current {
+			resubmission.Changes = append(resubmission.Changes, entity.TermChange{
+				Field:    term.field,
+				Previous: term.previous,
+				Current:  term.current,
+			})
+		}
+	}
+	loan.Resubmission = resubmission
+	return nil
+}
@@ -0,0 +1,249 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"loan-service/agreement"
+	"loan-service/entity"
+	"loan-service/statemachine"
+	"loan-service/usecase"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/money"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-redis/redismock/v9"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestLoanUsecase_ResubmitLoan(t *testing.T) {
+	const rejectedID = 7
+	const loanID = 8
+	const borrowerID = 2
+	roi := decimal.NewFromInt(5)
+	rate := decimal.NewFromInt(10)
+	principal := decimal.NewFromInt(800)
+
+	request := entity.RequestResubmitLoan{
+		LoanID: rejectedID,
+		RequestProposeLoan: entity.RequestProposeLoan{
+			Principal: principal,
+			ROI:       roi,
+			Rate:      rate,
+		},
+	}
+	expectRejected := func(mockSql sqlmock.Sqlmock, owner uint) {
+		mockSql.ExpectQuery(regexp.QuoteMeta(
+			`SELECT * FROM "loans" WHERE id = $1 AND status = $2`)).
+			WithArgs(rejectedID, constants.StatusRejected, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "borrower_id", "principal", "status"}).
+				AddRow(rejectedID, owner, 1000, constants.StatusRejected))
+	}
+	expectResubmissions := func(mockSql sqlmock.Sqlmock, count int) {
+		mockSql.ExpectQuery(regexp.QuoteMeta(
+			`SELECT count(*) FROM "loans" WHERE resubmission_of = $1`)).
+			WithArgs(rejectedID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+	}
+	expectAppeals := func(mockSql sqlmock.Sqlmock, count int) {
+		mockSql.ExpectQuery(regexp.QuoteMeta(
+			`SELECT count(*) FROM "loans" WHERE borrower_id = $1 AND resubmission_of IS NOT NULL`)).
+			WithArgs(borrowerID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+	}
+
+	tests := []struct {
+		name         string
+		policy       usecase.LoanPolicy
+		mockFunc     func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock)
+		wantDocument bool
+		wantErr      error
+	}{
+		{
+			name:   "ResubmitLoan_Success",
+			policy: usecase.LoanPolicy{MaxAppeals: 3},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				expectRejected(mockSql, borrowerID)
+				mockSql.ExpectBegin()
+				expectResubmissions(mockSql, 0)
+				expectAppeals(mockSql, 2)
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`INSERT INTO "loans"`)).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						borrowerID,
+						principal,
+						money.IDR,
+						rate,
+						roi,
+						constants.DefaultTenor,
+						constants.DefaultFrequency,
+						constants.StatusProposed,
+						nil,
+						agreement.CurrentLoanVersion,
+						nil,
+						nil,
+						rejectedID,
+					).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(loanID))
+				expectLoanEvent(mockSql, loanID, statemachine.EventPropose, statemachine.None, constants.StatusProposed, entity.Actor{ID: borrowerID, Role: constants.RoleBorrower})
+				expectAgreementBorrower(mockSql, borrowerID)
+				mockSql.ExpectExec(regexp.QuoteMeta(
+					`UPDATE "loans"`)).
+					WithArgs(
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						borrowerID,
+						principal,
+						money.IDR,
+						rate,
+						roi,
+						constants.DefaultTenor,
+						constants.DefaultFrequency,
+						constants.StatusProposed,
+						"https://documents.test/1",
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						sqlmock.AnyArg(),
+						rejectedID,
+						loanID,
+					).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mockSql.ExpectCommit()
+			},
+			wantDocument: true,
+		},
+		{
+			name: "ResubmitLoan_Failure_NotRejected",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`SELECT * FROM "loans" WHERE id = $1 AND status = $2`)).
+					WithArgs(rejectedID, constants.StatusRejected, 1).
+					WillReturnError(gorm.ErrRecordNotFound)
+			},
+			wantErr: fmt.Errorf(errs.ErrLoanNotResubmittable),
+		},
+		{
+			name: "ResubmitLoan_Failure_NotOwner",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				expectRejected(mockSql, borrowerID+1)
+			},
+			wantErr: fmt.Errorf(errs.ErrUnauthorizedAction),
+		},
+		{
+			name: "ResubmitLoan_Failure_ConcurrentResubmission",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				expectRejected(mockSql, borrowerID)
+				mockSql.ExpectBegin()
+				expectResubmissions(mockSql, 0)
+				// the other resubmission was committed after the count, the unique index rejects this one
+				mockSql.ExpectQuery(regexp.QuoteMeta(
+					`INSERT INTO "loans"`)).
+					WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_loans_resubmission_of"})
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf(errs.ErrLoanAlreadyResubmitted),
+		},
+		{
+			name: "ResubmitLoan_Failure_AlreadyResubmitted",
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				expectRejected(mockSql, borrowerID)
+				mockSql.ExpectBegin()
+				expectResubmissions(mockSql, 1)
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf(errs.ErrLoanAlreadyResubmitted),
+		},
+		{
+			name:   "ResubmitLoan_Failure_AppealLimitReached",
+			policy: usecase.LoanPolicy{MaxAppeals: 3},
+			mockFunc: func(mockSql sqlmock.Sqlmock, mockRedis redismock.ClientMock) {
+				expectRejected(mockSql, borrowerID)
+				mockSql.ExpectBegin()
+				expectResubmissions(mockSql, 0)
+				expectAppeals(mockSql, 3)
+				mockSql.ExpectRollback()
+			},
+			wantErr: fmt.Errorf(errs.ErrAppealLimitReached),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mockSql := setupMockDB(t)
+			redis, mockRedis := redismock.NewClientMock()
+
+			documents := &fakeDocuments{}
+			u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, documents, tt.policy)
+
+			if tt.mockFunc != nil {
+				tt.mockFunc(mockSql, mockRedis)
+			}
+
+			got, err := u.ResubmitLoan(context.Background(), request, entity.Actor{ID: borrowerID, Role: constants.RoleBorrower})
+			if tt.wantErr != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(loanID), got.ID)
+				assert.Equal(t, constants.StatusProposed, got.Status)
+				assert.Equal(t, uint(rejectedID), *got.ResubmissionOf)
+			}
+			assert.NoError(t, mockSql.ExpectationsWereMet())
+
+			if tt.wantDocument {
+				assertLoanAgreementDocument(t, documents, uint(loanID), uint(borrowerID))
+			} else {
+				assert.Empty(t, documents.saved)
+			}
+		})
+	}
+}
+
+func TestLoanUsecase_GetLoan_Resubmission(t *testing.T) {
+	db, mockSql := setupMockDB(t)
+	redis, _ := redismock.NewClientMock()
+	u := usecase.NewLoanUsecase(db, redis, &fakeNotifier{}, &fakeDocuments{}, usecase.LoanPolicy{})
+
+	mockSql.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "loans"`)).
+		WithArgs("8", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "principal", "currency", "rate", "roi", "tenor", "frequency", "resubmission_of"}).
+			AddRow(8, constants.StatusProposed, 800, money.IDR, 10, 5, 12, constants.FrequencyMonthly, 7))
+	for _, table := range []string{"loan_approvals", "loan_disbursements", "investments"} {
+		mockSql.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(`SELECT * FROM "%s"`, table))).
+			WithArgs(8).
+			WillReturnRows(sqlmock.NewRows([]string{"loan_id"}))
+	}
+	mockSql.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "loans"`)).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "principal", "currency", "rate", "roi", "tenor", "frequency"}).
+			AddRow(7, constants.StatusRejected, 1000, money.IDR, 10, 5, 24, constants.FrequencyMonthly))
+	mockSql.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "loan_approvals"`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"loan_id", "validator_id", "reject_reason"}).
+			AddRow(7, 3, "Tenor too long for the principal"))
+
+	got, err := u.GetLoan("8")
+	assert.NoError(t, err)
+	assert.NoError(t, mockSql.ExpectationsWereMet())
+
+	reason := "Tenor too long for the principal"
+	assert.Equal(t, &entity.LoanResubmission{
+		PreviousLoanID: 7,
+		RejectReason:   &reason,
+		Changes: []entity.TermChange{
+			{Field: "principal", Previous: "1000", Current: "800"},
+			{Field: "tenor", Previous: "24", Current: "12"},
+		},
+	}, got.Resubmission)
+}
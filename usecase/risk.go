@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"loan-service/entity"
+	"loan-service/utils/constants"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// RiskScorer grades the credit risk of a loan when it is approved
+type RiskScorer interface {
+	Score(ctx context.Context, loan entity.Loan, history entity.BorrowerHistory) (entity.RiskAssessment, error)
+}
+
+// RuleScorer is the default RiskScorer, it starts from a neutral score and adjusts it for the repayment record of
+// the borrower and the length of the loan
+type RuleScorer struct{}
+
+func (RuleScorer) Score(_ context.Context, loan entity.Loan, history entity.BorrowerHistory) (entity.RiskAssessment, error) {
+	score := 60
+	score += 10 * min(history.PaidOffLoans, 3)
+	score -= 30 * history.DefaultedLoans
+	score -= 5 * history.ActiveLoans
+	score -= 5 * min(history.RejectedLoans, 2)
+
+	months := loan.Tenor
+	if loan.Frequency == constants.FrequencyWeekly {
+		months = loan.Tenor / 4
+	}
+	switch {
+	case months > 24:
+		score -= 10
+	case months > 12:
+		score -= 5
+	}
+
+	score = max(0, min(score, 100))
+	return entity.RiskAssessment{Score: score, Grade: gradeOf(score)}, nil
+}
+
+// gradeOf maps a score to its grade in steps of 15 points, 80 and above is A
+func gradeOf(score int) constants.RiskGrade {
+	switch {
+	case score >= 80:
+		return constants.RiskGradeA
+	case score >= 65:
+		return constants.RiskGradeB
+	case score >= 50:
+		return constants.RiskGradeC
+	case score >= 35:
+		return constants.RiskGradeD
+	default:
+		return constants.RiskGradeE
+	}
+}
+
+// borrowerHistory counts the other loans of the borrower per outcome
+func borrowerHistory(db *gorm.DB, loan entity.Loan) (entity.BorrowerHistory, error) {
+	var counts []struct {
+		Status constants.LoanStatus
+		Count  int
+	}
+	if err := db.Model(&entity.Loan{}).
+		Select("status, COUNT(*) AS count").
+		Where("borrower_id = ? AND id <> ?", loan.BorrowerID, loan.ID).
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		return entity.BorrowerHistory{}, err
+	}
+
+	history := entity.BorrowerHistory{}
+	for _, c := range counts {
+		switch c.Status {
+		case constants.StatusPaidOff:
+			history.PaidOffLoans += c.Count
+		case constants.StatusDefaulted:
+			history.DefaultedLoans += c.Count
+		case constants.StatusRejected:
+			history.RejectedLoans += c.Count
+		case constants.StatusCancelled, constants.StatusExpired:
+		default:
+			history.ActiveLoans += c.Count
+		}
+	}
+	return history, nil
+}
+
+func validRiskGrade(grade constants.RiskGrade) bool {
+	return slices.Contains(constants.RiskGrades, grade)
+}
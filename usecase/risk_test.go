@@ -0,0 +1,63 @@
+package usecase_test
+
+import (
+	"context"
+	"loan-service/entity"
+	"loan-service/usecase"
+	"loan-service/utils/constants"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleScorer_Score(t *testing.T) {
+	monthly := entity.Loan{Tenor: 12, Frequency: constants.FrequencyMonthly}
+	tests := []struct {
+		name    string
+		loan    entity.Loan
+		history entity.BorrowerHistory
+		want    entity.RiskAssessment
+	}{
+		{
+			name: "new borrower",
+			loan: monthly,
+			want: entity.RiskAssessment{Score: 60, Grade: constants.RiskGradeC},
+		},
+		{
+			name:    "paid off loans are capped at three",
+			loan:    monthly,
+			history: entity.BorrowerHistory{PaidOffLoans: 5},
+			want:    entity.RiskAssessment{Score: 90, Grade: constants.RiskGradeA},
+		},
+		{
+			name:    "a default outweighs a paid off loan",
+			loan:    monthly,
+			history: entity.BorrowerHistory{PaidOffLoans: 1, DefaultedLoans: 1},
+			want:    entity.RiskAssessment{Score: 40, Grade: constants.RiskGradeD},
+		},
+		{
+			name:    "score does not go below zero",
+			loan:    monthly,
+			history: entity.BorrowerHistory{DefaultedLoans: 3},
+			want:    entity.RiskAssessment{Score: 0, Grade: constants.RiskGradeE},
+		},
+		{
+			name:    "active loans and long tenor",
+			loan:    entity.Loan{Tenor: 36, Frequency: constants.FrequencyMonthly},
+			history: entity.BorrowerHistory{ActiveLoans: 1},
+			want:    entity.RiskAssessment{Score: 45, Grade: constants.RiskGradeD},
+		},
+		{
+			name: "weekly tenor counts in months",
+			loan: entity.Loan{Tenor: 52, Frequency: constants.FrequencyWeekly},
+			want: entity.RiskAssessment{Score: 55, Grade: constants.RiskGradeC},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := usecase.RuleScorer{}.Score(context.Background(), tt.loan, tt.history)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
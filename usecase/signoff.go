@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"loan-service/entity"
+	"loan-service/statemachine"
+	"loan-service/utils/constants"
+	errs "loan-service/utils/errors"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// needsDualApproval tells whether the principal of the loan is above the dual approval threshold of its currency
+func (u *LoanUsecase) needsDualApproval(loan entity.Loan) bool {
+	threshold, ok := u.policy.DualApprovalThreshold[loan.Currency]
+	return ok && loan.Principal.GreaterThan(threshold)
+}
+
+// signOff records the sign-off on its loan and returns every sign-off of the loan so far, an approver can only sign
+// off a loan once and a sign-off with another risk grade or other ticket sizes than the earlier ones is rejected so
+// no override is lost
+func signOff(tx *gorm.DB, signoff entity.ApprovalSignoff) ([]entity.ApprovalSignoff, error) {
+	var signoffs []entity.ApprovalSignoff
+	if err := tx.Where("loan_id = ?", signoff.LoanID).Order("id").Find(&signoffs).Error; err != nil {
+		return nil, err
+	}
+	for _, s := range signoffs {
+		if s.ApproverID == signoff.ApproverID {
+			return nil, errors.New(errs.ErrAlreadySignedOff)
+		}
+		if s.RiskGrade != signoff.RiskGrade {
+			return nil, errors.New(errs.ErrRiskGradeMismatch)
+		}
+		if !sameBound(s.MinTicket, signoff.MinTicket) || !sameBound(s.MaxTicket, signoff.MaxTicket) {
+			return nil, errors.New(errs.ErrTicketSizeMismatch)
+		}
+	}
+
+	if err := tx.Create(&signoff).Error; err != nil {
+		return nil, err
+	}
+	return append(signoffs, signoff), nil
+}
+
+// sameBound tells whether two optional ticket sizes are both unset or the same amount
+func sameBound(a, b *decimal.Decimal) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// requireCountersignature guards the approve transition, a supervisor only counter-signs the loans that need dual
+// approval and can never approve a loan alone
+func (u *LoanUsecase) requireCountersignature(ctx context.Context, change statemachine.Change) error {
+	if change.Actor.Role == constants.RoleSupervisor && !u.needsDualApproval(*change.Loan) {
+		return errors.New(errs.ErrUnauthorizedAction)
+	}
+	return nil
+}
+
+// quorumReached is the maker-checker rule of large loans: two distinct approvers, at least one of them a validator.
+// A supervisor (or admin) can be the second approver but never both.
+func quorumReached(signoffs []entity.ApprovalSignoff) bool {
+	approvers := map[uint]bool{}
+	validator := false
+	for _, s := range signoffs {
+		approvers[s.ApproverID] = true
+		if s.Role == constants.RoleValidator {
+			validator = true
+		}
+	}
+	return len(approvers) >= 2 && validator
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"errors"
+	"loan-service/entity"
+	errs "loan-service/utils/errors"
+	"loan-service/utils/money"
+
+	"github.com/shopspring/decimal"
+)
+
+// ticketSize returns the smallest and largest investment allowed on the loan, zero means no limit
+func (u *LoanUsecase) ticketSize(loan entity.Loan) (decimal.Decimal, decimal.Decimal) {
+	min := u.policy.MinTicket[loan.Currency]
+	max := u.policy.MaxTicket[loan.Currency]
+	if loan.MinTicket != nil {
+		min = *loan.MinTicket
+	}
+	if loan.MaxTicket != nil {
+		max = *loan.MaxTicket
+	}
+	return min, max
+}
+
+// validTicketSize checks the ticket sizes a validator sets on a loan when approving it
+func validTicketSize(loan entity.Loan, min, max *decimal.Decimal) bool {
+	for _, bound := range []*decimal.Decimal{min, max} {
+		if bound == nil {
+			continue
+		}
+		if bound.IsNegative() || bound.GreaterThan(loan.Principal) || !money.FitsMinorUnits(*bound, loan.Currency) {
+			return false
+		}
+	}
+	return min == nil || max == nil || max.IsZero() || !min.GreaterThan(*max)
+}
+
+// checkTicketSize validates an investment against the ticket sizes of the loan, remaining is what is left to fund
+// before the investment. The last ticket may be below the minimum so the loan can always be fully funded.
+func (u *LoanUsecase) checkTicketSize(loan entity.Loan, amount, remaining decimal.Decimal) error {
+	min, max := u.ticketSize(loan)
+	if max.IsPositive() && amount.GreaterThan(max) {
+		return errors.New(errs.ErrInvestmentAboveMaxTicket)
+	}
+	if amount.LessThan(min) && !amount.Equal(remaining) {
+		return errors.New(errs.ErrInvestmentBelowMinTicket)
+	}
+	return nil
+}
@@ -2,9 +2,12 @@ package config
 
 import (
 	"loan-service/utils/auth"
+	"loan-service/utils/money"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
 )
 
 type Config struct {
@@ -17,6 +20,35 @@ type Config struct {
 	RedisHost string `env:"REDIS_HOST"`
 	RedisPort string `env:"REDIS_PORT"`
 
+	SMTPHost string `env:"SMTP_HOST"`
+	SMTPPort string `env:"SMTP_PORT"`
+	SMTPUser string `env:"SMTP_USER"`
+	SMTPPass string `env:"SMTP_PASS"`
+	SMTPFrom string `env:"SMTP_FROM"`
+
+	NotificationRetryInterval time.Duration   `env:"NOTIFICATION_RETRY_INTERVAL"`
+	FundingWindow             time.Duration   `env:"FUNDING_WINDOW"`
+	LoanExpiryInterval        time.Duration   `env:"LOAN_EXPIRY_INTERVAL"`
+	InvestmentGracePeriod     time.Duration   `env:"INVESTMENT_GRACE_PERIOD" envDefault:"24h"`
+	MinTicket                 money.Amounts   `env:"MIN_TICKET"`
+	MaxTicket                 money.Amounts   `env:"MAX_TICKET"`
+	MaxInvestorLoanShare      decimal.Decimal `env:"MAX_INVESTOR_LOAN_SHARE"`
+	MaxBorrowerExposure       money.Amounts   `env:"MAX_BORROWER_EXPOSURE"`
+	MaxBorrowerActiveLoans    int             `env:"MAX_BORROWER_ACTIVE_LOANS"`
+	MaxBorrowerOutstanding    money.Amounts   `env:"MAX_BORROWER_OUTSTANDING"`
+	DualApprovalThreshold     money.Amounts   `env:"DUAL_APPROVAL_THRESHOLD"`
+	MaxAppeals                int             `env:"MAX_APPEALS" envDefault:"3"`
+
+	DocumentStore string `env:"DOCUMENT_STORE"`
+	DocumentDir   string `env:"DOCUMENT_DIR"`
+	S3Endpoint    string `env:"S3_ENDPOINT"`
+	S3Region      string `env:"S3_REGION"`
+	S3Bucket      string `env:"S3_BUCKET"`
+	S3AccessKey   string `env:"S3_ACCESS_KEY"`
+	S3SecretKey   string `env:"S3_SECRET_KEY"`
+	PublicURL     string `env:"PUBLIC_URL"`
+	MaxUploadSize int64  `env:"MAX_UPLOAD_SIZE"`
+
 	AuthSecret string `env:"AUTH_SECRET"`
 	Authorizer *auth.Authorizer
 }
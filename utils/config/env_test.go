@@ -5,6 +5,7 @@ import (
 	"loan-service/utils/config"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -38,6 +39,9 @@ func TestLoadConfig(t *testing.T) {
 				RedisHost:  "redis",
 				RedisPort:  "6379",
 				AuthSecret: "secret",
+				// unset settings with a default take it
+				InvestmentGracePeriod: 24 * time.Hour,
+				MaxAppeals:            3,
 			},
 			wantErr: false,
 			cleanupFunc: func() {
@@ -52,6 +56,19 @@ func TestLoadConfig(t *testing.T) {
 				fmt.Printf("db_host1: %s\n", os.Getenv("DB_HOST"))
 			},
 		},
+		{
+			name: "zero disables investment cancellation and the appeal limit",
+			envVars: map[string]string{
+				"INVESTMENT_GRACE_PERIOD": "0",
+				"MAX_APPEALS":             "0",
+			},
+			want:    config.Config{},
+			wantErr: false,
+			cleanupFunc: func() {
+				os.Unsetenv("INVESTMENT_GRACE_PERIOD")
+				os.Unsetenv("MAX_APPEALS")
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -64,7 +81,8 @@ func TestLoadConfig(t *testing.T) {
 			// Make sure we clean up after the test
 			defer tt.cleanupFunc()
 
-			// Execute the function
+			// Execute the function on a fresh config
+			config.Conf = config.Config{}
 			err := config.LoadConfig()
 
 			// Assert results
@@ -1,5 +1,7 @@
 package constants
 
+import "time"
+
 type LoanStatus string
 
 const (
@@ -8,8 +10,112 @@ const (
 	StatusRejected  LoanStatus = "rejected"
 	StatusInvested  LoanStatus = "invested"
 	StatusDisbursed LoanStatus = "disbursed"
+	StatusRepaying  LoanStatus = "repaying"
+	StatusPaidOff   LoanStatus = "paid_off"
+	StatusDefaulted LoanStatus = "defaulted"
+	StatusCancelled LoanStatus = "cancelled"
+	StatusExpired   LoanStatus = "expired"
+)
+
+type InvestmentStatus string
+
+const (
+	InvestmentActive    InvestmentStatus = "active"
+	InvestmentVoided    InvestmentStatus = "voided"
+	InvestmentCancelled InvestmentStatus = "cancelled"
+)
+
+type InstallmentStatus string
+
+const (
+	InstallmentPending InstallmentStatus = "pending"
+	InstallmentPartial InstallmentStatus = "partial"
+	InstallmentPaid    InstallmentStatus = "paid"
+)
+
+type RepaymentFrequency string
+
+const (
+	FrequencyWeekly  RepaymentFrequency = "weekly"
+	FrequencyMonthly RepaymentFrequency = "monthly"
+)
+
+const (
+	DefaultTenor     = 12
+	DefaultFrequency = FrequencyMonthly
+)
+
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "pending"
+	NotificationSent    NotificationStatus = "sent"
+	NotificationFailed  NotificationStatus = "failed"
+)
+
+type NotificationKind string
+
+const (
+	NotificationLoanInvested NotificationKind = "loan_invested"
+	NotificationLoanExpired  NotificationKind = "loan_expired"
+)
+
+// DocumentKind is what a stored document is for, it decides who besides its owner can read it
+type DocumentKind string
+
+const (
+	DocumentLoanAgreement       DocumentKind = "loan_agreement"
+	DocumentInvestmentAgreement DocumentKind = "investment_agreement"
+	// DocumentApprovalPhoto is the photo a validator takes of the borrower on approval, DocumentSignedAgreement the scan
+	// of the agreement signed by the borrower on disbursement. Both are uploaded by staff.
+	DocumentApprovalPhoto   DocumentKind = "approval_photo"
+	DocumentSignedAgreement DocumentKind = "signed_agreement"
+)
+
+const (
+	// DocumentStoreFilesystem keeps the documents under DOCUMENT_DIR, DocumentStoreS3 in an S3 compatible bucket
+	DocumentStoreFilesystem = "filesystem"
+	DocumentStoreS3         = "s3"
+	DefaultDocumentDir      = "documents"
+	DefaultPublicURL        = "http://localhost:8080"
+	// DefaultMaxUploadSize is the largest file accepted by the upload endpoints when MAX_UPLOAD_SIZE is not set
+	DefaultMaxUploadSize = 10 << 20
+	// MaxUploadPixels caps the dimensions of an uploaded image, it is decoded in full to be re-encoded
+	MaxUploadPixels = 50_000_000
+)
+
+// MaxSerializableAttempts is how often a serializable transaction is run when concurrent transactions abort it
+const MaxSerializableAttempts = 3
+
+const (
+	MaxNotificationAttempts          = 5
+	DefaultNotificationRetryInterval = 5 * time.Minute
 )
 
+const (
+	DefaultFundingWindow      = 14 * 24 * time.Hour
+	DefaultLoanExpiryInterval = time.Hour
+	// DefaultMinTicket is the smallest investment per currency when MIN_TICKET is not set
+	DefaultMinTicket = "IDR:100000,USD:10,SGD:10,JPY:1000"
+)
+
+const (
+	DefaultLoanPageSize = 20
+)
+
+// RiskGrade is the credit risk of a loan from A (lowest) to E (highest)
+type RiskGrade string
+
+const (
+	RiskGradeA RiskGrade = "A"
+	RiskGradeB RiskGrade = "B"
+	RiskGradeC RiskGrade = "C"
+	RiskGradeD RiskGrade = "D"
+	RiskGradeE RiskGrade = "E"
+)
+
+var RiskGrades = []RiskGrade{RiskGradeA, RiskGradeB, RiskGradeC, RiskGradeD, RiskGradeE}
+
 type UserRole string
 
 const (
@@ -18,7 +124,11 @@ const (
 	RoleValidator UserRole = "validator"
 	RoleInvestor  UserRole = "investor"
 	RoleDisburser UserRole = "disburser"
-	RoleUnknown   UserRole = "unknown"
+	// RoleSupervisor signs off large loans together with a validator
+	RoleSupervisor UserRole = "supervisor"
+	RoleUnknown    UserRole = "unknown"
+	// RoleSystem is used by the background jobs, it is not assignable to a user
+	RoleSystem UserRole = "system"
 )
 
 var RoleMap = []UserRole{
@@ -27,4 +137,5 @@ var RoleMap = []UserRole{
 	RoleValidator,
 	RoleInvestor,
 	RoleDisburser,
+	RoleSupervisor,
 }
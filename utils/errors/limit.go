@@ -0,0 +1,15 @@
+package errs
+
+import "fmt"
+
+// LimitExceededError is returned when an action would take an exposure over a configured risk limit, Message is one
+// of the limit messages above and Current and Limit are the formatted exposure and limit, ie. "500.00 IDR"
+type LimitExceededError struct {
+	Message string
+	Current string
+	Limit   string
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s: current exposure is %s, limit is %s", e.Message, e.Current, e.Limit)
+}
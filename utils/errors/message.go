@@ -2,13 +2,49 @@ package errs
 
 const (
 	// Error messages
-	ErrInvestmentExceedsPrincipal = "Investment exceeds principal amount"
-	ErrLoanNotFound               = "Loan not found"
-	ErrLoanNotFoundApprover       = "Loan not found or already approved"
-	ErrLockAcquisitionFailed      = "Failed to acquire lock for investment processing"
-	ErrBusySystem                 = "System is busy, please try again later"
-	ErrUserNotFound               = "Failed to find user"
-	ErrUnauthorizedAction         = "Unauthorized action for the user role"
+	ErrInvestmentExceedsPrincipal     = "Investment exceeds principal amount"
+	ErrLoanNotFound                   = "Loan not found"
+	ErrLoanNotFoundApprover           = "Loan not found or already approved"
+	ErrLockAcquisitionFailed          = "Failed to acquire lock for investment processing"
+	ErrBusySystem                     = "System is busy, please try again later"
+	ErrConcurrentUpdate               = "Loan was changed by a concurrent request, please try again"
+	ErrUserNotFound                   = "Failed to find user"
+	ErrUnauthorizedAction             = "Unauthorized action for the user role"
+	ErrInvalidLoanTerms               = "Invalid loan terms"
+	ErrScheduleNotAvailable           = "Repayment schedule is not available until the loan is disbursed"
+	ErrLoanNotRepayable               = "Loan not found or not open for repayment"
+	ErrRepaymentExceedsOutstanding    = "Repayment exceeds outstanding amount"
+	ErrLoanNotOverdue                 = "Loan not found or has no overdue installment"
+	ErrInvalidLoanFilter              = "Invalid loan filter"
+	ErrInvalidCursor                  = "Invalid cursor"
+	ErrInvalidTransition              = "Invalid loan status transition"
+	ErrLoanNotCancellable             = "Loan not found or can no longer be cancelled"
+	ErrInvestmentNotCancellable       = "Investment not found or can no longer be cancelled"
+	ErrInvestmentGraceExpired         = "Investment grace period has expired"
+	ErrUnsupportedCurrency            = "Unsupported currency"
+	ErrInvalidMinorUnits              = "Amount has more decimal places than the currency allows"
+	ErrCurrencyMismatch               = "Investment currency does not match the loan currency"
+	ErrInvalidTicketSize              = "Invalid investment ticket size"
+	ErrInvestmentBelowMinTicket       = "Investment is below the minimum ticket size of the loan"
+	ErrInvestmentAboveMaxTicket       = "Investment is above the maximum ticket size of the loan"
+	ErrInvalidRiskGrade               = "Invalid risk grade, expected one of A, B, C, D or E"
+	ErrRiskGradeJustificationRequired = "Overriding the risk grade requires a justification"
+	ErrAlreadySignedOff               = "Loan is already signed off by this approver"
+	ErrRiskGradeMismatch              = "Risk grade differs from the grade of the earlier sign-off"
+	ErrTicketSizeMismatch             = "Ticket sizes differ from the ticket sizes of the earlier sign-off"
+	ErrLoanNotResubmittable           = "Loan not found or not rejected"
+	ErrLoanAlreadyResubmitted         = "Loan has already been resubmitted"
+	ErrAppealLimitReached             = "Borrower has reached the limit of loan resubmissions"
+	ErrDocumentNotFound               = "Document not found"
+	ErrDocumentTooLarge               = "Document exceeds the upload size limit"
+	ErrUnsupportedDocument            = "Unsupported document type or unreadable file"
+	ErrDocumentNotAttached            = "Document not found or not uploaded for this loan"
+
+	// Limit errors, returned as LimitExceededError
+	ErrInvestorLoanShareExceeded   = "Investment exceeds the share of the loan a single investor can hold"
+	ErrInvestorExposureExceeded    = "Investment exceeds the investor exposure limit to the borrower"
+	ErrBorrowerActiveLoansExceeded = "Borrower has reached the limit of active loans"
+	ErrBorrowerOutstandingExceeded = "Loan would take the borrower over the outstanding principal limit"
 
 	//Authentication errors
 	ErrAuthUninitialized = "Authorizer is not initialized"
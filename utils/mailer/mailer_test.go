@@ -0,0 +1,149 @@
+package mailer_test
+
+import (
+	"bufio"
+	"context"
+	"loan-service/utils/mailer"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpStandIn is a minimal local SMTP server that records what it receives
+type smtpStandIn struct {
+	listener net.Listener
+	mu       sync.Mutex
+	from     string
+	rcpt     []string
+	data     string
+	done     chan struct{}
+}
+
+func startSMTPStandIn(t *testing.T, rejectRcpt bool) *smtpStandIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	s := &smtpStandIn{listener: l, done: make(chan struct{})}
+	go s.serve(rejectRcpt)
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *smtpStandIn) serve(rejectRcpt bool) {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			s.mu.Unlock()
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			if rejectRcpt {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			s.mu.Lock()
+			s.rcpt = append(s.rcpt, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var body strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				body.WriteString(dataLine)
+			}
+			s.mu.Lock()
+			s.data = body.String()
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	tests := []struct {
+		name       string
+		msg        mailer.Message
+		rejectRcpt bool
+		wantErr    bool
+	}{
+		{
+			name: "delivers message to the relay",
+			msg: mailer.Message{
+				To:      []string{"investor1@example.com", "investor2@example.com"},
+				Subject: "Loan #1 is fully funded",
+				Body:    "Hello\nAgreement: https://example.com/loans/1/loan_proposal_1.pdf",
+			},
+		},
+		{
+			name: "relay rejects recipient",
+			msg: mailer.Message{
+				To:      []string{"unknown@example.com"},
+				Subject: "Loan #1 is fully funded",
+				Body:    "Hello",
+			},
+			rejectRcpt: true,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startSMTPStandIn(t, tt.rejectRcpt)
+			host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+			m := mailer.NewSMTPMailer(host, port, "", "", "no-reply@loan-service.local")
+
+			err := m.Send(context.Background(), tt.msg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			<-server.done
+
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			assert.Equal(t, "no-reply@loan-service.local", server.from)
+			assert.Equal(t, tt.msg.To, server.rcpt)
+			assert.Contains(t, server.data, "Subject: "+tt.msg.Subject+"\r\n")
+			assert.Contains(t, server.data, "Agreement: https://example.com/loans/1/loan_proposal_1.pdf\r\n")
+		})
+	}
+}
+
+func TestSMTPMailer_Send_NoRecipient(t *testing.T) {
+	m := mailer.NewSMTPMailer("127.0.0.1", "1", "", "", "no-reply@loan-service.local")
+	assert.Error(t, m.Send(context.Background(), mailer.Message{Subject: "empty"}))
+}
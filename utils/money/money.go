@@ -0,0 +1,87 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const (
+	IDR Currency = "IDR"
+	USD Currency = "USD"
+	SGD Currency = "SGD"
+	JPY Currency = "JPY"
+)
+
+// Default is the currency of the platform, amounts without a currency are rounded with its rule
+const Default = IDR
+
+// Rule is how the amounts of a currency are rounded
+type Rule struct {
+	// Places is the number of minor unit digits of the currency
+	Places int32
+}
+
+var rules = map[Currency]Rule{
+	IDR: {Places: 2},
+	USD: {Places: 2},
+	SGD: {Places: 2},
+	JPY: {Places: 0},
+}
+
+// RuleOf returns the rounding rule of a currency, unknown currencies fall back to the rule of Default
+func RuleOf(currency Currency) Rule {
+	if rule, ok := rules[currency]; ok {
+		return rule
+	}
+	return rules[Default]
+}
+
+// Round rounds an amount to the minor unit of the currency, ties are rounded away from zero
+func Round(amount decimal.Decimal, currency Currency) decimal.Decimal {
+	return amount.Round(RuleOf(currency).Places)
+}
+
+// Supported reports whether the platform accepts amounts in the currency
+func Supported(currency Currency) bool {
+	_, ok := rules[currency]
+	return ok
+}
+
+// FitsMinorUnits reports whether the amount has no more decimal places than the currency, ie. 10.005 USD does not fit
+func FitsMinorUnits(amount decimal.Decimal, currency Currency) bool {
+	return amount.Equal(amount.Truncate(RuleOf(currency).Places))
+}
+
+// Format writes an amount with every minor unit digit of the currency, ie. 1000 IDR is "1000.00"
+func Format(amount decimal.Decimal, currency Currency) string {
+	return Round(amount, currency).StringFixed(RuleOf(currency).Places)
+}
+
+// Amounts holds at most one amount per currency, ie. a limit that depends on the currency
+type Amounts map[Currency]decimal.Decimal
+
+// UnmarshalText reads the amounts from a comma separated list of currency:amount pairs, ie. "IDR:100000,USD:10"
+func (a *Amounts) UnmarshalText(text []byte) error {
+	amounts := Amounts{}
+	for _, pair := range strings.Split(string(text), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		currency, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			return fmt.Errorf("invalid amount %q, expected currency:amount", pair)
+		}
+		amount, err := decimal.NewFromString(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid amount %q: %w", pair, err)
+		}
+		amounts[Currency(strings.ToUpper(strings.TrimSpace(currency)))] = amount
+	}
+	*a = amounts
+	return nil
+}
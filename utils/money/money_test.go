@@ -0,0 +1,66 @@
+package money_test
+
+import (
+	"loan-service/utils/money"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency money.Currency
+		want     string
+	}{
+		{name: "IDR rounds to cents", amount: "83.335", currency: money.IDR, want: "83.34"},
+		{name: "USD rounds half away from zero", amount: "-0.125", currency: money.USD, want: "-0.13"},
+		{name: "JPY has no minor unit", amount: "1000.5", currency: money.JPY, want: "1001"},
+		{name: "Unknown currency uses default rule", amount: "10.005", currency: money.Currency("XYZ"), want: "10.01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := money.Round(decimal.RequireFromString(tt.amount), tt.currency)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestRuleOf(t *testing.T) {
+	assert.Equal(t, money.Rule{Places: 0}, money.RuleOf(money.JPY))
+	assert.Equal(t, money.RuleOf(money.Default), money.RuleOf(money.Currency("XYZ")))
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "1000.00", money.Format(decimal.NewFromInt(1000), money.IDR))
+	assert.Equal(t, "83.34", money.Format(decimal.RequireFromString("83.335"), money.USD))
+	assert.Equal(t, "1001", money.Format(decimal.RequireFromString("1000.5"), money.JPY))
+}
+
+func TestFitsMinorUnits(t *testing.T) {
+	assert.True(t, money.FitsMinorUnits(decimal.RequireFromString("10.05"), money.USD))
+	assert.False(t, money.FitsMinorUnits(decimal.RequireFromString("10.005"), money.USD))
+	assert.True(t, money.FitsMinorUnits(decimal.NewFromInt(1000), money.JPY))
+	assert.False(t, money.FitsMinorUnits(decimal.RequireFromString("1000.5"), money.JPY))
+}
+
+func TestSupported(t *testing.T) {
+	assert.True(t, money.Supported(money.SGD))
+	assert.False(t, money.Supported("EUR"))
+}
+
+func TestAmounts_UnmarshalText(t *testing.T) {
+	var amounts money.Amounts
+	assert.NoError(t, amounts.UnmarshalText([]byte("IDR:100000, usd:10.5")))
+	assert.Len(t, amounts, 2)
+	assert.Equal(t, "100000", amounts[money.IDR].String())
+	assert.Equal(t, "10.5", amounts[money.USD].String())
+
+	assert.NoError(t, amounts.UnmarshalText([]byte("")))
+	assert.Empty(t, amounts)
+
+	assert.Error(t, amounts.UnmarshalText([]byte("IDR")))
+	assert.Error(t, amounts.UnmarshalText([]byte("IDR:ten")))
+}
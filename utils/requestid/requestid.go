@@ -0,0 +1,26 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header is the HTTP header carrying the request ID, an ID sent by the client is kept
+const Header = "X-Request-ID"
+
+// ContextKey is the key of the request ID in the gin context, gin resolves string keys from its own values
+const ContextKey = "requestID"
+
+// New generates a random request ID
+func New() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// FromContext returns the request ID of the context or an empty string when there is none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ContextKey).(string)
+	return id
+}
@@ -0,0 +1,113 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"loan-service/utils/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Put(ctx, "documents/ab/abcdef", []byte("%PDF-1.3 agreement"), "application/pdf"))
+	assert.NoError(t, store.Put(ctx, "documents/ab/abcdef", []byte("%PDF-1.3 agreement"), "application/pdf"))
+
+	body, err := store.Get(ctx, "documents/ab/abcdef")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+	assert.Equal(t, "%PDF-1.3 agreement", string(content))
+
+	_, err = store.Get(ctx, "documents/ab/missing")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	assert.NoError(t, store.Delete(ctx, "documents/ab/abcdef"))
+	_, err = store.Get(ctx, "documents/ab/abcdef")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.NoError(t, store.Delete(ctx, "documents/ab/abcdef"))
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "documents/../../outside", "documents//double"} {
+		assert.Error(t, store.Put(ctx, key, []byte("x"), "text/plain"), key)
+		_, err := store.Get(ctx, key)
+		assert.Error(t, err, key)
+		assert.Error(t, store.Delete(ctx, key), key)
+	}
+}
+
+// s3StandIn is a minimal S3 compatible bucket that records the requests it receives
+type s3StandIn struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	types    map[string]string
+	requests []*http.Request
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=minio/") {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		s.objects[r.URL.Path] = body
+		s.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := s.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+	standIn := &s3StandIn{objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	store := storage.NewS3Store(server.URL, "us-east-1", "documents", "minio", "minio123")
+	assert.NoError(t, store.Put(ctx, "documents/ab/abcdef", []byte("%PDF-1.3 agreement"), "application/pdf"))
+	assert.Equal(t, "application/pdf", standIn.types["/documents/documents/ab/abcdef"])
+
+	body, err := store.Get(ctx, "documents/ab/abcdef")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+	assert.Equal(t, "%PDF-1.3 agreement", string(content))
+
+	_, err = store.Get(ctx, "documents/ab/missing")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	assert.NoError(t, store.Delete(ctx, "documents/ab/abcdef"))
+	assert.NotContains(t, standIn.objects, "/documents/documents/ab/abcdef")
+
+	for _, req := range standIn.requests {
+		assert.Contains(t, req.Header.Get("Authorization"), "/us-east-1/s3/aws4_request,SignedHeaders=")
+		assert.NotEmpty(t, req.Header.Get("X-Amz-Date"))
+		assert.NotEmpty(t, req.Header.Get("X-Amz-Content-Sha256"))
+	}
+
+	denied := storage.NewS3Store(server.URL, "us-east-1", "documents", "someone", "secret")
+	err = denied.Put(ctx, "documents/ab/abcdef", []byte("x"), "text/plain")
+	assert.ErrorContains(t, err, "403 Forbidden")
+}